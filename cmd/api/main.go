@@ -1,23 +1,322 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/tiago123456789/own-githubaction/internal/config"
 	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/executor"
 	"github.com/tiago123456789/own-githubaction/internal/middleware"
 	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/runner"
 	"github.com/tiago123456789/own-githubaction/internal/service"
 	"github.com/tiago123456789/own-githubaction/internal/types"
+	"github.com/tiago123456789/own-githubaction/pkg/alert"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
 	"github.com/tiago123456789/own-githubaction/pkg/file"
+	"github.com/tiago123456789/own-githubaction/pkg/github"
 	"github.com/tiago123456789/own-githubaction/pkg/logger"
+	"github.com/tiago123456789/own-githubaction/pkg/oidc"
 	"github.com/tiago123456789/own-githubaction/pkg/queue"
 	secretmanager "github.com/tiago123456789/own-githubaction/pkg/secret_manager"
+	"github.com/tiago123456789/own-githubaction/pkg/sshkey"
+	"github.com/tiago123456789/own-githubaction/pkg/workflow"
 )
 
+func getActor(c *fiber.Ctx) types.Actor {
+	return types.Actor{
+		Name: middleware.GetUser(c).Name,
+		Ip:   c.IP(),
+	}
+}
+
+// getProjectId returns the project of the route, the middleware of
+// authorization already refuses the invalid ids.
+func getProjectId(c *fiber.Ctx) uint {
+	projectId, _ := strconv.Atoi(c.Params(middleware.ProjectParam))
+	return uint(projectId)
+}
+
+func getAuditFilter(c *fiber.Ctx) (types.AuditFilter, error) {
+	filter := types.AuditFilter{
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+	}
+
+	if len(c.Query("triggerId")) > 0 {
+		triggerId, err := strconv.Atoi(c.Query("triggerId"))
+		if err != nil || triggerId < 0 {
+			return filter, errors.New("The query triggerId must be a number")
+		}
+		filter.TriggerId = uint(triggerId)
+	}
+
+	for name, value := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if len(c.Query(name)) == 0 {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, c.Query(name))
+		if err != nil {
+			return filter, fmt.Errorf("The query %s must be a date like 2024-01-02T15:04:05Z", name)
+		}
+		*value = date
+	}
+
+	return filter, nil
+}
+
+type secretOwnerFinder func(c *fiber.Ctx) (service.SecretOwner, error)
+
+func registerSecretRoutes(
+	app fiber.Router, path string, secretService *service.SecretService, findOwner secretOwnerFinder,
+	canRead fiber.Handler, canWrite fiber.Handler,
+) {
+	app.Get(path+"/secrets", canRead, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		secrets, err := secretService.GetSecrets(owner, getActor(c))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		return c.JSON(secrets)
+	})
+
+	app.Get(path+"/secrets-audit", canRead, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(secretService.GetSecretAudits(owner))
+	})
+
+	app.Put(path+"/secrets/:key", canWrite, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err := service.ValidateSecretKey(c.Params("key")); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		secretValue := &types.SecretValue{}
+		if err := c.BodyParser(secretValue); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if len(secretValue.Value) == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "The field value is required",
+			})
+		}
+
+		secret, err := secretService.SetSecret(
+			owner, c.Params("key"), secretValue.Value, getActor(c),
+		)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		return c.JSON(secret)
+	})
+
+	app.Put(path+"/secrets/:key/policy", canWrite, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		policy := &types.SecretPolicy{}
+		if err := c.BodyParser(policy); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if err := service.ValidateSecretPolicy(*policy); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		secret, err := secretService.SetSecretPolicy(owner, c.Params("key"), *policy, getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		return c.JSON(secret)
+	})
+
+	app.Delete(path+"/secrets/:key", canWrite, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		err = secretService.DeleteSecret(owner, c.Params("key"), getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		return c.SendStatus(204)
+	})
+
+	app.Post(path+"/secrets/rotate", canWrite, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		rotation := &types.SecretsRotation{}
+		if err := c.BodyParser(rotation); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		for key := range rotation.Secrets {
+			if err := service.ValidateSecretKey(key); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		secrets, err := secretService.RotateSecrets(owner, rotation.Secrets, getActor(c))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		return c.JSON(secrets)
+	})
+}
+
+type variableOwnerFinder func(c *fiber.Ctx) (service.VariableOwner, error)
+
+func registerVariableRoutes(
+	app fiber.Router, path string, variableService *service.VariableService, findOwner variableOwnerFinder,
+	canRead fiber.Handler, canWrite fiber.Handler,
+) {
+	app.Get(path+"/vars", canRead, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(variableService.GetVariables(owner))
+	})
+
+	app.Get(path+"/vars-history", canRead, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(variableService.GetHistory(owner))
+	})
+
+	app.Put(path+"/vars/:key", canWrite, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err := service.ValidateSecretKey(c.Params("key")); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		variableValue := &types.VariableValue{}
+		if err := c.BodyParser(variableValue); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(variableService.SetVariable(
+			owner, c.Params("key"), variableValue.Value, getActor(c),
+		))
+	})
+
+	app.Delete(path+"/vars/:key", canWrite, func(c *fiber.Ctx) error {
+		owner, err := findOwner(c)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err := variableService.DeleteVariable(owner, c.Params("key"), getActor(c)); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.SendStatus(204)
+	})
+}
+
 func main() {
 	err := godotenv.Load()
 	if err != nil {
@@ -27,15 +326,57 @@ func main() {
 	db := config.GetDB()
 	db.AutoMigrate(
 		&entities.Trigger{}, &entities.Execution{},
-		&entities.ExecutionLog{},
+		&entities.ExecutionLog{}, &entities.Runner{},
+		&entities.Secret{}, &entities.SecretAudit{},
+		&entities.SecretGroup{}, &entities.Variable{},
+		&entities.VariableHistory{}, &entities.User{},
+		&entities.TriggerMember{}, &entities.ApiToken{},
+		&entities.Session{}, &entities.AuditEvent{},
+		&entities.Project{}, &entities.ProjectMember{},
 	)
+	repository.ProtectAuditEvents(db)
+	repository.MigrateToProjects(db)
 
 	logger := logger.Get()
-	secretManager := secretmanager.New(true)
+	if ids := repository.TriggersWithRepositoryToken(db); len(ids) > 0 {
+		if os.Getenv("DROP_REPOSITORY_TOKENS") != "true" {
+			log.Fatalf(
+				"The triggers %v still have a repository token. Install the Github App on their repositories, or create them again with a deploy key, then start with DROP_REPOSITORY_TOKENS=true to remove the tokens",
+				ids,
+			)
+		}
+
+		logger.Warn(fmt.Sprintf("Removed the repository token of the triggers %v", ids))
+	}
+	repository.DropRepositoryTokens(db)
+	secretManager := secretmanager.New(true, db, logger)
 	producerQueue := queue.NewProducer("pipeline_executions")
 	defer producerQueue.Close()
 
+	githubClient := github.New()
 	triggerRepository := repository.NewTriggerRepository(db)
+	projectRepository := repository.NewProjectRepository(db)
+	auditService := service.NewAuditService(
+		repository.NewAuditRepository(db), logger,
+	)
+	secretService := service.NewSecretService(
+		repository.NewSecretRepository(db),
+		repository.NewSecretGroupRepository(db),
+		triggerRepository,
+		projectRepository,
+		secretManager,
+		logger,
+		auditService,
+	)
+	variableService := service.NewVariableService(
+		repository.NewVariableRepository(db),
+		triggerRepository,
+		auditService,
+	)
+	executors := executor.NewRegistry()
+	runnerService := service.NewRunnerService(
+		repository.NewRunnerRepository(db), logger,
+	)
 
 	triggerService := service.NewTriggerService(
 		secretManager,
@@ -43,12 +384,51 @@ func main() {
 		triggerRepository,
 		queue.NewQueueUtil(),
 		file.New(logger),
+		githubClient,
+		runner.NewAdmission("pipelines"),
+		executors,
+		encryption.New(),
+		secretService,
+		variableService,
+		alert.New(logger),
+		auditService,
+	)
+
+	userRepository := repository.NewUserRepository(db)
+	userService := service.NewUserService(
+		userRepository, triggerRepository,
+		repository.NewApiTokenRepository(db),
+		projectRepository,
+		auditService,
+	)
+	projectService := service.NewProjectService(
+		projectRepository, userRepository, auditService,
 	)
+	authService := service.NewAuthService(
+		userService,
+		userRepository,
+		repository.NewSessionRepository(db),
+		projectRepository,
+		oidc.New(),
+		encryption.New(),
+		logger,
+		auditService,
+	)
+	can := func(permission string) fiber.Handler {
+		return middleware.HasAuthorization(authService.Authenticate, userService.Can, permission, "")
+	}
+	canOnTrigger := func(permission string) fiber.Handler {
+		return middleware.HasAuthorization(authService.Authenticate, userService.Can, permission, "id")
+	}
+	isAuthenticated := can("")
 
 	app := fiber.New()
+	projects := app.Group("/projects/:" + middleware.ProjectParam)
 
-	app.Post("/triggers-execute/:hash", middleware.HasValidSecret, func(c *fiber.Ctx) error {
-		execution, err := triggerService.Execute(c.Params("hash"))
+	app.Post("/triggers-execute/:hash", middleware.HasValidSecret(triggerService.GetSigningSecrets), func(c *fiber.Ctx) error {
+		execution, err := triggerService.Execute(
+			c.Params("hash"), c.Get("X-GitHub-Event"), c.Body(),
+		)
 		if err != nil {
 			return c.Status(404).JSON(fiber.Map{
 				"message": "Not found register",
@@ -58,60 +438,885 @@ func main() {
 		return c.JSON(execution)
 	})
 
-	app.Get("/triggers/:id/executions", middleware.HasAuthorization, func(c *fiber.Ctx) error {
+	projects.Get("/triggers/:id/executions", canOnTrigger(service.PermissionTriggersRead), func(c *fiber.Ctx) error {
 		return c.JSON(triggerService.GetExecutionsByTriggerId(c.Params("id")))
 	})
 
-	app.Get("/triggers/:id/executions/:executionId/logs", middleware.HasAuthorization, func(c *fiber.Ctx) error {
+	projects.Get("/triggers/:id/executions/:executionId/logs", canOnTrigger(service.PermissionLogsRead), func(c *fiber.Ctx) error {
 		return c.JSON(triggerService.GetExecutionLogsByTriggerIdAndExecutionId(
 			c.Params("id"),
 			c.Params("executionId"),
 		))
 	})
 
-	app.Get("/triggers", middleware.HasAuthorization, func(c *fiber.Ctx) error {
-		return c.JSON(triggerService.GetTriggers())
+	app.Get("/runners", isAuthenticated, func(c *fiber.Ctx) error {
+		return c.JSON(runnerService.GetRunners())
 	})
 
-	app.Post("/triggers", middleware.HasAuthorization, func(c *fiber.Ctx) error {
-		trigger := &types.Trigger{}
-		if err := c.BodyParser(trigger); err != nil {
+	projects.Post("/triggers/:id/rotate-secret", canOnTrigger(service.PermissionTriggersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		rotation := &types.SecretRotation{GraceMinutes: 24 * 60}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(rotation); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		if rotation.GraceMinutes < 0 {
 			return c.Status(400).JSON(fiber.Map{
-				"message": err.Error(),
+				"message": "The field graceMinutes can't be negative",
 			})
 		}
 
-		if len(trigger.ActionToRun) == 0 {
+		secret, err := triggerService.RotateSigningSecret(
+			uint(id), time.Duration(rotation.GraceMinutes)*time.Minute, getActor(c),
+		)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"secret": secret,
+		})
+	})
+
+	registerSecretRoutes(projects, "/triggers/:id", secretService, func(c *fiber.Ctx) (service.SecretOwner, error) {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return service.SecretOwner{}, service.ErrNotFound
+		}
+
+		return secretService.TriggerOwner(getProjectId(c), uint(id))
+	}, canOnTrigger(service.PermissionSecretsRead), canOnTrigger(service.PermissionSecretsWrite))
+
+	registerSecretRoutes(projects, "/secret-groups/:id", secretService, func(c *fiber.Ctx) (service.SecretOwner, error) {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return service.SecretOwner{}, service.ErrNotFound
+		}
+
+		return secretService.GroupOwner(getProjectId(c), uint(id))
+	}, can(service.PermissionSecretsRead), can(service.PermissionSecretsWrite))
+
+	registerSecretRoutes(projects, "", secretService, func(c *fiber.Ctx) (service.SecretOwner, error) {
+		return secretService.ProjectOwner(getProjectId(c))
+	}, can(service.PermissionSecretsRead), can(service.PermissionSecretsWrite))
+
+	registerVariableRoutes(projects, "/triggers/:id", variableService, func(c *fiber.Ctx) (service.VariableOwner, error) {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return service.VariableOwner{}, service.ErrNotFound
+		}
+
+		return variableService.TriggerOwner(getProjectId(c), uint(id))
+	}, canOnTrigger(service.PermissionTriggersRead), canOnTrigger(service.PermissionTriggersWrite))
+
+	registerVariableRoutes(projects, "/environments/:name", variableService, func(c *fiber.Ctx) (service.VariableOwner, error) {
+		return variableService.EnvironmentOwner(getProjectId(c), c.Params("name"))
+	}, can(service.PermissionTriggersRead), can(service.PermissionTriggersWrite))
+
+	projects.Get("/secret-groups", can(service.PermissionSecretsRead), func(c *fiber.Ctx) error {
+		return c.JSON(secretService.GetGroups(getProjectId(c)))
+	})
+
+	projects.Post("/secret-groups", can(service.PermissionSecretsWrite), func(c *fiber.Ctx) error {
+		group := &types.SecretGroup{}
+		if err := c.BodyParser(group); err != nil {
 			return c.Status(400).JSON(fiber.Map{
-				"message": "The field actionToRun is required",
+				"message": err.Error(),
 			})
 		}
 
-		if len(trigger.LinkRepository) == 0 {
+		if err := service.ValidateSecretGroupName(group.Name); err != nil {
 			return c.Status(400).JSON(fiber.Map{
-				"message": "The field linkRepository is required",
+				"message": err.Error(),
 			})
 		}
 
-		if trigger.IsPrivate == true && len(trigger.RepositoryToken) == 0 {
+		newGroup, err := secretService.CreateGroup(getProjectId(c), *group, getActor(c))
+		if err != nil {
 			return c.Status(400).JSON(fiber.Map{
-				"message": "When repository is private the field repositoryToken is required",
+				"message": err.Error(),
 			})
 		}
 
-		trigger.Hash = uuid.NewString()
+		return c.JSON(newGroup)
+	})
 
-		webhookUrl, err := triggerService.Save(*trigger)
+	projects.Put("/secret-groups/:id/allowed-triggers", can(service.PermissionSecretsWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Internal server error",
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
 			})
 		}
 
-		return c.JSON(types.NewTrigger{
-			WebhookUrl:   webhookUrl,
-			GithubSecret: trigger.Hash,
-		})
+		group := &types.SecretGroup{}
+		if err := c.BodyParser(group); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		updatedGroup, err := secretService.UpdateGroupTriggers(
+			getProjectId(c), uint(id), group.AllowedTriggers, getActor(c),
+		)
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(updatedGroup)
+	})
+
+	projects.Put("/triggers/:id/secret-groups", canOnTrigger(service.PermissionSecretsWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		triggerSecretGroups := &types.TriggerSecretGroups{}
+		if err := c.BodyParser(triggerSecretGroups); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		err = secretService.SetTriggerGroups(uint(id), triggerSecretGroups.SecretGroups, getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"secretGroups": triggerSecretGroups.SecretGroups,
+		})
+	})
+
+	projects.Post("/triggers/:id/executions", canOnTrigger(service.PermissionExecutionsRun), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		manualRun := &types.ManualRun{}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(manualRun); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		execution, err := triggerService.Run(uint(id), manualRun.Ref, getActor(c))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(execution)
+	})
+
+	projects.Post("/triggers/:id/executions/:executionId/cancel", canOnTrigger(service.PermissionExecutionsCancel), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		execution, err := triggerService.Cancel(uint(id), c.Params("executionId"), getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(409).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(execution)
+	})
+
+	projects.Get("/triggers/:id/members", canOnTrigger(service.PermissionTriggersRead), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		members, err := userService.GetMembers(uint(id))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(members)
+	})
+
+	projects.Put("/triggers/:id/members/:userId", canOnTrigger(service.PermissionMembersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		userId, err := strconv.Atoi(c.Params("userId"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		userRole := &types.UserRole{}
+		if err := c.BodyParser(userRole); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		member, err := userService.SetMember(uint(id), uint(userId), userRole.Role, getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(member)
+	})
+
+	projects.Delete("/triggers/:id/members/:userId", canOnTrigger(service.PermissionMembersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		userId, err := strconv.Atoi(c.Params("userId"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err := userService.DeleteMember(uint(id), uint(userId), getActor(c)); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.SendStatus(204)
+	})
+
+	app.Get("/projects", isAuthenticated, func(c *fiber.Ctx) error {
+		return c.JSON(projectService.GetProjects(middleware.GetUser(c)))
+	})
+
+	app.Post("/projects", can(service.PermissionProjectsWrite), func(c *fiber.Ctx) error {
+		project := &types.Project{}
+		if err := c.BodyParser(project); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		newProject, err := projectService.CreateProject(*project, getActor(c))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(newProject)
+	})
+
+	projects.Get("/members", can(service.PermissionTriggersRead), func(c *fiber.Ctx) error {
+		members, err := projectService.GetMembers(getProjectId(c))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(members)
+	})
+
+	projects.Put("/members/:userId", can(service.PermissionMembersWrite), func(c *fiber.Ctx) error {
+		userId, err := strconv.Atoi(c.Params("userId"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		member, err := projectService.AddMember(getProjectId(c), uint(userId), getActor(c))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.JSON(member)
+	})
+
+	projects.Delete("/members/:userId", can(service.PermissionMembersWrite), func(c *fiber.Ctx) error {
+		userId, err := strconv.Atoi(c.Params("userId"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err := projectService.DeleteMember(getProjectId(c), uint(userId), getActor(c)); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.SendStatus(204)
+	})
+
+	app.Get("/users", can(service.PermissionUsersWrite), func(c *fiber.Ctx) error {
+		return c.JSON(userService.GetUsers())
+	})
+
+	app.Post("/users", can(service.PermissionUsersWrite), func(c *fiber.Ctx) error {
+		user := &types.User{}
+		if err := c.BodyParser(user); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		newUser, err := userService.CreateUser(*user, getActor(c))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(newUser)
+	})
+
+	app.Put("/users/:id/role", can(service.PermissionUsersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		userRole := &types.UserRole{}
+		if err := c.BodyParser(userRole); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		user, err := userService.UpdateRole(uint(id), userRole.Role, getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(user)
+	})
+
+	app.Post("/users/:id/rotate-api-key", can(service.PermissionUsersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		apiKey, err := userService.RotateApiKey(uint(id), getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"apiKey": apiKey,
+		})
+	})
+
+	app.Delete("/users/:id", can(service.PermissionUsersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err := userService.DeleteUser(uint(id), getActor(c)); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.SendStatus(204)
+	})
+
+	secureCookies := strings.HasPrefix(os.Getenv("API_BASE_URL"), "https://")
+
+	app.Get("/auth/oidc/login", func(c *fiber.Ctx) error {
+		authUrl, loginState, err := authService.StartLogin()
+		if errors.Is(err, service.ErrOidcDisabled) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		// Lax, because the provider redirects back to the callback from other
+		// site.
+		c.Cookie(&fiber.Cookie{
+			Name:     "oidc_login",
+			Value:    loginState,
+			Path:     "/auth/oidc",
+			MaxAge:   600,
+			HTTPOnly: true,
+			Secure:   secureCookies,
+			SameSite: "Lax",
+		})
+
+		return c.Redirect(authUrl)
+	})
+
+	app.Get("/auth/oidc/callback", func(c *fiber.Ctx) error {
+		c.Cookie(&fiber.Cookie{
+			Name:     "oidc_login",
+			Path:     "/auth/oidc",
+			Expires:  time.Now().Add(-time.Hour),
+			HTTPOnly: true,
+			Secure:   secureCookies,
+		})
+		if len(c.Query("error")) > 0 {
+			return c.Status(401).JSON(fiber.Map{
+				"message": c.Query("error"),
+			})
+		}
+
+		user, token, expiresAt, err := authService.FinishLogin(
+			c.Query("code"), c.Query("state"), c.Cookies("oidc_login"), c.IP(),
+		)
+		if errors.Is(err, service.ErrForbidden) {
+			return c.Status(403).JSON(fiber.Map{
+				"message": "Your groups don't have a role on this api",
+			})
+		}
+
+		if err != nil {
+			return c.Status(401).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		c.Cookie(&fiber.Cookie{
+			Name:     middleware.SessionCookie,
+			Value:    token,
+			Path:     "/",
+			Expires:  expiresAt,
+			HTTPOnly: true,
+			Secure:   secureCookies,
+			SameSite: "Strict",
+		})
+
+		if redirectUrl := os.Getenv("OIDC_POST_LOGIN_URL"); len(redirectUrl) > 0 {
+			return c.Redirect(redirectUrl)
+		}
+
+		return c.JSON(user)
+	})
+
+	app.Post("/auth/logout", isAuthenticated, func(c *fiber.Ctx) error {
+		authService.Logout(c.Cookies(middleware.SessionCookie), getActor(c))
+		c.Cookie(&fiber.Cookie{
+			Name:     middleware.SessionCookie,
+			Path:     "/",
+			Expires:  time.Now().Add(-time.Hour),
+			HTTPOnly: true,
+			Secure:   secureCookies,
+		})
+		return c.SendStatus(204)
+	})
+
+	app.Get("/me", isAuthenticated, func(c *fiber.Ctx) error {
+		return c.JSON(middleware.GetUser(c))
+	})
+
+	app.Get("/tokens", isAuthenticated, func(c *fiber.Ctx) error {
+		apiTokens, err := userService.GetApiTokens(middleware.GetUser(c))
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{
+				"message": "You don't have permission to do that action",
+			})
+		}
+
+		return c.JSON(apiTokens)
+	})
+
+	app.Post("/tokens", isAuthenticated, func(c *fiber.Ctx) error {
+		apiToken := &types.ApiToken{}
+		if err := c.BodyParser(apiToken); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		newApiToken, err := userService.CreateApiToken(middleware.GetUser(c), *apiToken, getActor(c))
+		if errors.Is(err, service.ErrForbidden) {
+			return c.Status(403).JSON(fiber.Map{
+				"message": "You don't have permission to do that action",
+			})
+		}
+
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(newApiToken)
+	})
+
+	app.Delete("/tokens/:id", isAuthenticated, func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		err = userService.DeleteApiToken(middleware.GetUser(c), uint(id), getActor(c))
+		if errors.Is(err, service.ErrForbidden) {
+			return c.Status(403).JSON(fiber.Map{
+				"message": "You don't have permission to do that action",
+			})
+		}
+
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.SendStatus(204)
+	})
+
+	app.Get("/audit-events", can(service.PermissionAuditRead), func(c *fiber.Ctx) error {
+		filter, err := getAuditFilter(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(auditService.GetEvents(filter, c.QueryInt("page", 1), c.QueryInt("limit", 50)))
+	})
+
+	app.Get("/audit-events/export", can(service.PermissionAuditRead), func(c *fiber.Ctx) error {
+		filter, err := getAuditFilter(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		c.Set("Content-Type", "application/x-ndjson")
+		c.Set("Content-Disposition", "attachment; filename=audit-events.jsonl")
+		c.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+			err := auditService.Export(filter, func(line []byte) error {
+				_, err := writer.Write(line)
+				return err
+			})
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to export audit events: %v", err))
+			}
+			writer.Flush()
+		})
+
+		return nil
+	})
+
+	projects.Get("/triggers", isAuthenticated, func(c *fiber.Ctx) error {
+		user := middleware.GetUser(c)
+		triggers := []entities.Trigger{}
+		for _, trigger := range triggerService.GetTriggers(getProjectId(c)) {
+			if userService.Can(user, service.PermissionTriggersRead, getProjectId(c), trigger.ID) {
+				triggers = append(triggers, trigger)
+			}
+		}
+
+		return c.JSON(triggers)
+	})
+
+	projects.Put("/triggers/:id", canOnTrigger(service.PermissionTriggersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		trigger := &types.Trigger{}
+		if err := c.BodyParser(trigger); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if err := runner.ValidateLabels(trigger.Labels); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("The field labels is invalid: %v", err),
+			})
+		}
+
+		if trigger.Resources.MemoryMb < 0 || trigger.Resources.Cpus < 0 || trigger.Resources.DiskMb < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "The field resources can't have negative values",
+			})
+		}
+
+		if len(trigger.Environment) > 0 {
+			if err := service.ValidateEnvironment(trigger.Environment); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		updated, err := triggerService.Update(uint(id), *trigger, getActor(c))
+		if errors.Is(err, service.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(updated)
+	})
+
+	projects.Delete("/triggers/:id", canOnTrigger(service.PermissionTriggersWrite), func(c *fiber.Ctx) error {
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		if err := triggerService.Delete(uint(id), getActor(c)); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Not found register",
+			})
+		}
+
+		return c.SendStatus(204)
+	})
+
+	projects.Post("/triggers", can(service.PermissionTriggersCreate), func(c *fiber.Ctx) error {
+		trigger := &types.Trigger{}
+		if err := c.BodyParser(trigger); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if _, ok := executors.Get(trigger.Executor); !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "The field executor must be act, script or native",
+			})
+		}
+
+		if len(trigger.ActionToRun) == 0 && trigger.Executor != "script" {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "The field actionToRun is required",
+			})
+		}
+
+		if len(trigger.LinkRepository) == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "The field linkRepository is required",
+			})
+		}
+
+		if err := github.ValidateRepositoryLink(trigger.LinkRepository); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		validateActionToRun := workflow.ValidateFileName
+		if trigger.Executor == "script" {
+			validateActionToRun = workflow.ValidateScriptPath
+		}
+
+		if len(trigger.ActionToRun) > 0 {
+			if err := validateActionToRun(trigger.ActionToRun); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		if len(trigger.RepositoryToken) > 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "The field repositoryToken isn't accepted, use the Github App or the field deployKey or generateDeployKey",
+			})
+		}
+
+		hasDeployKey := trigger.GenerateDeployKey || len(trigger.DeployKey) > 0
+		if trigger.IsPrivate == true && !hasDeployKey && !githubClient.HasApp() {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "When repository is private and the Github App isn't configured the field deployKey or generateDeployKey is required",
+			})
+		}
+
+		if len(trigger.DeployKey) > 0 {
+			if err := sshkey.Validate(trigger.DeployKey); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		if err := runner.ValidateLabels(trigger.Labels); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("The field labels is invalid: %v", err),
+			})
+		}
+
+		if trigger.Resources.MemoryMb < 0 || trigger.Resources.Cpus < 0 || trigger.Resources.DiskMb < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "The field resources can't have negative values",
+			})
+		}
+
+		for key := range trigger.Envs {
+			if err := service.ValidateSecretKey(key); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		for key := range trigger.Vars {
+			if err := service.ValidateSecretKey(key); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		if len(trigger.Environment) > 0 {
+			if err := service.ValidateEnvironment(trigger.Environment); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+		}
+
+		trigger.ProjectId = getProjectId(c)
+		if err := secretService.ValidateGroups(trigger.ProjectId, trigger.SecretGroups); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		trigger.Hash = uuid.NewString()
+
+		newTrigger, err := triggerService.Save(*trigger, getActor(c))
+		var labelsErr runner.LabelsError
+		if errors.As(err, &labelsErr) {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+
+		if user := middleware.GetUser(c); user.ID != 0 {
+			userService.SetMember(newTrigger.ID, user.ID, service.RoleTriggerOwner, getActor(c))
+		}
+
+		return c.JSON(newTrigger)
 	})
 
 	app.Listen(":3000")
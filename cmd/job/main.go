@@ -2,12 +2,19 @@ package main
 
 import (
 	"log"
+	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/tiago123456789/own-githubaction/internal/config"
+	"github.com/tiago123456789/own-githubaction/internal/executor"
 	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/runner"
 	"github.com/tiago123456789/own-githubaction/internal/service"
+	"github.com/tiago123456789/own-githubaction/pkg/alert"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
 	"github.com/tiago123456789/own-githubaction/pkg/file"
+	"github.com/tiago123456789/own-githubaction/pkg/github"
 	"github.com/tiago123456789/own-githubaction/pkg/logger"
 	"github.com/tiago123456789/own-githubaction/pkg/queue"
 	secretmanager "github.com/tiago123456789/own-githubaction/pkg/secret_manager"
@@ -21,23 +28,74 @@ func main() {
 
 	db := config.GetDB()
 	logger := logger.Get()
-	secretManager := secretmanager.New(true)
+	secretManager := secretmanager.New(true, db, logger)
 
 	producerQueue := queue.NewProducer("pipeline_executions")
 	defer producerQueue.Close()
 
+	files := file.New(logger)
+	files.RemoveStaleSecretFiles()
+
 	triggerRepository := repository.NewTriggerRepository(db)
+	auditService := service.NewAuditService(
+		repository.NewAuditRepository(db), logger,
+	)
+	secretService := service.NewSecretService(
+		repository.NewSecretRepository(db),
+		repository.NewSecretGroupRepository(db),
+		triggerRepository,
+		repository.NewProjectRepository(db),
+		secretManager,
+		logger,
+		auditService,
+	)
+	variableService := service.NewVariableService(
+		repository.NewVariableRepository(db),
+		triggerRepository,
+		auditService,
+	)
+	executors := executor.NewRegistry()
+	admission := runner.NewAdmission("pipelines")
 	triggerService := service.NewTriggerService(
 		secretManager,
 		logger, producerQueue,
 		triggerRepository,
 		queue.NewQueueUtil(),
-		file.New(logger),
+		files,
+		github.New(),
+		admission,
+		executors,
+		encryption.New(),
+		secretService,
+		variableService,
+		alert.New(logger),
+		auditService,
+	)
+
+	runnerLabels := runner.ParseLabels(os.Getenv("RUNNER_LABELS"))
+	if err := runner.ValidateLabels(runnerLabels); err != nil {
+		log.Fatalf("RUNNER_LABELS is invalid: %v", err)
+	}
+
+	runnerName := os.Getenv("RUNNER_NAME")
+	if len(runnerName) == 0 {
+		runnerName, _ = os.Hostname()
+	}
+
+	runnerService := service.NewRunnerService(
+		repository.NewRunnerRepository(db), logger,
 	)
+	go func() {
+		for {
+			runnerService.ReportCapacity(runnerName, runnerLabels, admission)
+			time.Sleep(30 * time.Second)
+		}
+	}()
 
 	consumerQueue := queue.NewConsumer(
 		"pipeline_executions",
 		triggerService.ProcessPipeline,
+		runner.ListenQueues(runnerLabels)...,
 	)
 
 	consumerQueue.Listen()
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/tiago123456789/own-githubaction/internal/config"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/service"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
+	"github.com/tiago123456789/own-githubaction/pkg/logger"
+)
+
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	db := config.GetDB()
+	logger := logger.Get()
+
+	encryptionService := service.NewEncryptionService(
+		repository.NewTriggerRepository(db),
+		encryption.New(),
+		logger,
+	)
+
+	total, err := encryptionService.Reencrypt()
+	if err != nil {
+		log.Fatalf("Failed to encrypt again: %v", err)
+	}
+
+	fmt.Printf("Encrypted again %d registers with the active key\n", total)
+}
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApiToken is a credential of the user restricted to some scopes, like a
+// token for a deploy bot. Only the hash of the token is stored.
+type ApiToken struct {
+	gorm.Model
+	UserId     uint       `json:"userId" gorm:"index"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex"`
+	Scopes     string     `json:"scopes"`
+	Cidr       string     `json:"cidr"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
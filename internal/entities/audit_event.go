@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent is an administrative action. The events are only appended, so
+// it doesn't have UpdatedAt and DeletedAt like the other entities.
+type AuditEvent struct {
+	ID        uint            `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time       `json:"createdAt" gorm:"index"`
+	Action    string          `json:"action" gorm:"index"`
+	Actor     string          `json:"actor" gorm:"index"`
+	Ip        string          `json:"ip"`
+	TriggerId uint            `json:"triggerId" gorm:"index"`
+	Resource  string          `json:"resource"`
+	Changes   json.RawMessage `json:"changes" gorm:"type:text"`
+}
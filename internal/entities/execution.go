@@ -7,4 +7,13 @@ type Execution struct {
 	ID        string `json:"id"`
 	TriggerId uint   `json:"triggerId"`
 	Status    string `json:"status"`
+	// StatusReason explains why the execution is Errored or Failed.
+	StatusReason string `json:"statusReason"`
+	Event        string `json:"event"`
+	Ref          string `json:"ref"`
+	// SecretLeakSuspected is set when the log had a credential that wasn't
+	// a secret of the execution, like an AWS key printed by a tool.
+	SecretLeakSuspected bool `json:"secretLeakSuspected"`
+	// Deferrals counts the times no job process had the resources requested.
+	Deferrals int `json:"deferrals"`
 }
@@ -0,0 +1,19 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Project owns triggers, secrets, environments and members, so a team only
+// sees and runs the triggers of the projects it is member of.
+type Project struct {
+	gorm.Model
+	Name string `json:"name" gorm:"uniqueIndex"`
+	// SecretPrefix names the project secrets on the secret manager. The
+	// default project keeps "org", the prefix of the secrets before projects.
+	SecretPrefix string `json:"-"`
+}
+
+type ProjectMember struct {
+	gorm.Model
+	ProjectId uint `json:"projectId" gorm:"index"`
+	UserId    uint `json:"userId" gorm:"index"`
+}
@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Runner struct {
+	gorm.Model
+	Name         string    `json:"name" gorm:"uniqueIndex"`
+	Labels       string    `json:"labels"`
+	Cpus         int       `json:"cpus"`
+	Load         float64   `json:"load"`
+	FreeMemoryMb int       `json:"freeMemoryMb"`
+	FreeDiskMb   int       `json:"freeDiskMb"`
+	Running      int       `json:"running"`
+	LastSeenAt   time.Time `json:"lastSeenAt"`
+}
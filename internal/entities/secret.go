@@ -0,0 +1,33 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Secret is one version of a secret of the trigger, of the group when GroupId
+// is set, or of the project when ProjectId is set. The value is stored on the
+// secret manager, the table only keeps which versions exist.
+type Secret struct {
+	gorm.Model
+	TriggerId uint   `json:"triggerId" gorm:"index"`
+	GroupId   uint   `json:"groupId" gorm:"index;default:0"`
+	ProjectId uint   `json:"projectId" gorm:"index;default:0"`
+	Key       string `json:"key"`
+	Version   int    `json:"version"`
+	UpdatedBy string `json:"updatedBy"`
+	// Branches, Events and Environments restrict which executions receive
+	// the secret. Empty means no restriction.
+	Branches     string `json:"branches"`
+	Events       string `json:"events"`
+	Environments string `json:"environments"`
+}
+
+type SecretAudit struct {
+	gorm.Model
+	TriggerId uint   `json:"triggerId" gorm:"index"`
+	GroupId   uint   `json:"groupId" gorm:"index;default:0"`
+	ProjectId uint   `json:"projectId" gorm:"index;default:0"`
+	Key       string `json:"key"`
+	Version   int    `json:"version"`
+	Action    string `json:"action"`
+	Actor     string `json:"actor"`
+	Ip        string `json:"ip"`
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "gorm.io/gorm"
+
+// SecretGroup has secrets shared by several triggers. Only the triggers on
+// AllowedTriggers receive the secrets, even when other triggers reference it.
+type SecretGroup struct {
+	gorm.Model
+	ProjectId       uint   `json:"projectId" gorm:"uniqueIndex:idx_secret_groups_project_name"`
+	Name            string `json:"name" gorm:"uniqueIndex:idx_secret_groups_project_name"`
+	Hash            string `json:"-"`
+	AllowedTriggers string `json:"allowedTriggers"`
+}
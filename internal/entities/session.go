@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is the login of an user on the OIDC provider, sent on a cookie.
+// Only the hash of the session token is stored.
+type Session struct {
+	gorm.Model
+	UserId    uint      `json:"userId" gorm:"index"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
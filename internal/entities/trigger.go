@@ -1,13 +1,45 @@
 package entities
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/internal/types"
+	"gorm.io/gorm"
+)
 
 type Trigger struct {
 	gorm.Model
-	Hash            string `json:"hash"`
-	ActionToRun     string `json:"actionToRun"`
-	LinkRepository  string `json:"linkRepository"`
-	IsPrivate       bool   `json:"isPrivate"`
-	RepositoryToken string `json:"repositoryToken"`
-	HasEnvs         bool   `json:"hasEnvs"`
+	ProjectId       uint            `json:"projectId" gorm:"index"`
+	Hash            string          `json:"hash"`
+	ActionToRun     string          `json:"actionToRun"`
+	LinkRepository  string          `json:"linkRepository"`
+	IsPrivate       bool            `json:"isPrivate"`
+	HasEnvs         bool            `json:"hasEnvs"`
+	Labels          string          `json:"labels"`
+	Resources       types.Resources `json:"resources" gorm:"embedded;embeddedPrefix:resource_"`
+	Executor        string          `json:"executor"`
+	HasDeployKey    bool            `json:"hasDeployKey"`
+	DeployPublicKey string          `json:"deployPublicKey"`
+	SigningSecret   string          `json:"-"`
+	SecretGroups    string          `json:"secretGroups"`
+	Environment     string          `json:"environment"`
+	// PreviousSigningSecret stays valid until PreviousSecretExpiresAt, so the
+	// webhook keeps working while the new secret is set on Github.
+	PreviousSigningSecret   string     `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previousSecretExpiresAt"`
+}
+
+// Masked returns the trigger safe to show on the api responses.
+func (t Trigger) Masked() Trigger {
+	// The triggers created before the signing secret existed use the hash as
+	// secret, and it stays valid during the grace window of the first rotation.
+	isLegacySecretValid := len(t.SigningSecret) == 0 ||
+		(t.PreviousSecretExpiresAt != nil && time.Now().Before(*t.PreviousSecretExpiresAt))
+	if isLegacySecretValid && len(t.Hash) > 4 {
+		t.Hash = "****" + t.Hash[len(t.Hash)-4:]
+	}
+	t.SigningSecret = ""
+	t.PreviousSigningSecret = ""
+
+	return t
 }
@@ -0,0 +1,27 @@
+package entities
+
+import "gorm.io/gorm"
+
+type User struct {
+	gorm.Model
+	Name       string `json:"name" gorm:"uniqueIndex"`
+	Role       string `json:"role"`
+	ApiKeyHash string `json:"-" gorm:"index"`
+	// OidcSubject is the sub claim of the users created by the OIDC login.
+	OidcSubject string `json:"-" gorm:"index"`
+	// Disabled users can't authenticate, like the OIDC users whose groups
+	// don't have a role anymore.
+	Disabled bool `json:"disabled"`
+	// TokenScopes limits the permissions when the user is authenticated by an
+	// api token.
+	TokenScopes []string `json:"-" gorm:"-"`
+}
+
+// TriggerMember gives a user a role on one trigger, so a trigger-owner only
+// acts on the triggers they are member of.
+type TriggerMember struct {
+	gorm.Model
+	TriggerId uint   `json:"triggerId" gorm:"index"`
+	UserId    uint   `json:"userId" gorm:"index"`
+	Role      string `json:"role"`
+}
@@ -0,0 +1,29 @@
+package entities
+
+import "gorm.io/gorm"
+
+// Variable is a plain text configuration of the trigger, or of every trigger of
+// the environment of the project when Environment is set. Unlike the secrets,
+// the value is returned on the api responses.
+type Variable struct {
+	gorm.Model
+	TriggerId   uint   `json:"triggerId" gorm:"index"`
+	ProjectId   uint   `json:"projectId" gorm:"index;default:0"`
+	Environment string `json:"environment" gorm:"index"`
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	UpdatedBy   string `json:"updatedBy"`
+}
+
+type VariableHistory struct {
+	gorm.Model
+	TriggerId     uint   `json:"triggerId" gorm:"index"`
+	ProjectId     uint   `json:"projectId" gorm:"index;default:0"`
+	Environment   string `json:"environment" gorm:"index"`
+	Key           string `json:"key"`
+	Action        string `json:"action"`
+	PreviousValue string `json:"previousValue"`
+	Value         string `json:"value"`
+	Actor         string `json:"actor"`
+	Ip            string `json:"ip"`
+}
@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/tiago123456789/own-githubaction/pkg/workflow"
+)
+
+// dockerEnvs are the envs of the worker act needs to reach Docker, besides the
+// allowed ones.
+var dockerEnvs = []string{"DOCKER_HOST", "DOCKER_CONFIG", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY"}
+
+type Act struct {
+}
+
+func NewAct() *Act {
+	return &Act{}
+}
+
+func (a *Act) Prepare(job Job) error {
+	return clone(job)
+}
+
+func (a *Act) Run(job Job, onLog LogHandler) error {
+	actionToRun := job.Execution.Trigger.ActionToRun
+	if err := workflow.ValidateFileName(actionToRun); err != nil {
+		return err
+	}
+
+	args := []string{"-W", filepath.Join(".github", "workflows", actionToRun)}
+	if len(job.SecretFile) > 0 {
+		secretFile, err := filepath.Abs(job.SecretFile)
+		if err != nil {
+			return err
+		}
+
+		args = append(args, "--secret-file", secretFile)
+	}
+
+	if len(job.VarFile) > 0 {
+		varFile, err := filepath.Abs(job.VarFile)
+		if err != nil {
+			return err
+		}
+
+		args = append(args, "--var-file", varFile)
+	}
+
+	cmd := exec.Command("act", args...)
+	cmd.Dir = job.Workspace
+	cmd.Env = pipelineEnv(lookupEnvs(dockerEnvs))
+
+	return streamOutput(cmd, onLog)
+}
+
+func (a *Act) Cleanup(job Job) error {
+	return os.RemoveAll(job.Workspace)
+}
+
+func lookupEnvs(keys []string) map[string]string {
+	envs := map[string]string{}
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			envs[key] = value
+		}
+	}
+
+	return envs
+}
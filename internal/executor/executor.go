@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+type LogHandler func(line string)
+
+type Job struct {
+	Execution       types.Execution
+	Workspace       string
+	SecretFile      string
+	Secrets         map[string]string
+	VarFile         string
+	Vars            map[string]string
+	DeployKey       string
+	RepositoryToken string
+}
+
+type IExecutor interface {
+	Prepare(job Job) error
+	Run(job Job, onLog LogHandler) error
+	Cleanup(job Job) error
+}
+
+type Registry map[string]IExecutor
+
+// allowedEnvs are the only envs of the worker the pipelines receive. The others,
+// like ENCRYPTION_KEYS, the tokens of the secret managers and GITHUB_APP_*, are
+// never inherited.
+var allowedEnvs = []string{"PATH", "HOME", "LANG", "TMPDIR"}
+
+// pipelineEnv returns the allowed envs of the worker followed by the envs
+// given, so the envs given override the worker ones.
+func pipelineEnv(envs ...map[string]string) []string {
+	env := []string{}
+	for _, key := range allowedEnvs {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+
+	for _, values := range envs {
+		for key, value := range values {
+			env = append(env, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+
+	return env
+}
+
+const Default = "act"
+
+func NewRegistry() Registry {
+	return Registry{
+		Default:  NewAct(),
+		"script": NewScript(),
+		"native": NewNative(),
+	}
+}
+
+func (r Registry) Get(name string) (IExecutor, bool) {
+	if len(name) == 0 {
+		name = Default
+	}
+
+	executor, ok := r[name]
+	return executor, ok
+}
+
+func streamOutput(cmd *exec.Cmd, onLog LogHandler) error {
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdoutPipe)
+	for scanner.Scan() {
+		onLog(scanner.Text())
+	}
+
+	scanErr := scanner.Err()
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	return scanErr
+}
+
+func streamCombinedOutput(cmd *exec.Cmd, onLog LogHandler) error {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	err = cmd.Start()
+	writer.Close()
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		onLog(scanner.Text())
+	}
+
+	scanErr := scanner.Err()
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	return scanErr
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+func TestPipelineEnvDoesntInheritWorkerEnvs(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEYS", "key1:master")
+	t.Setenv("VAULT_TOKEN", "vault-token")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", "private-key")
+	t.Setenv("PATH", "/usr/bin")
+
+	env := pipelineEnv(map[string]string{"CI": "true"}, map[string]string{"TOKEN": "job-secret"})
+	joined := strings.Join(env, "\n")
+	for _, leaked := range []string{"ENCRYPTION_KEYS", "VAULT_TOKEN", "GITHUB_APP_PRIVATE_KEY"} {
+		if strings.Contains(joined, leaked) {
+			t.Errorf("the env %s of the worker was given to the pipeline", leaked)
+		}
+	}
+
+	for _, expected := range []string{"PATH=/usr/bin", "CI=true", "TOKEN=job-secret"} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("expected %s on the env of the pipeline, got %v", expected, env)
+		}
+	}
+}
+
+func TestScriptDoesntReceiveWorkerEnvs(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEYS", "key1:master")
+	workspace := t.TempDir()
+	script := "env\n"
+	if err := os.WriteFile(filepath.Join(workspace, DefaultScript), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{}
+	job := Job{
+		Workspace: workspace,
+		Vars:      map[string]string{"REGION": "us-east-1"},
+		Secrets:   map[string]string{"TOKEN": "job-secret"},
+	}
+	if err := NewScript().Run(job, func(line string) { lines = append(lines, line) }); err != nil {
+		t.Fatal(err)
+	}
+
+	output := strings.Join(lines, "\n")
+	if strings.Contains(output, "ENCRYPTION_KEYS") {
+		t.Error("the script received the env ENCRYPTION_KEYS of the worker")
+	}
+
+	for _, expected := range []string{"REGION=us-east-1", "TOKEN=job-secret", "CI=true"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %s on the env of the script, got %s", expected, output)
+		}
+	}
+}
+
+func TestActDoesntReceiveWorkerEnvs(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "act"), []byte("#!/bin/sh\nenv\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("ENCRYPTION_KEYS", "key1:master")
+	t.Setenv("DOCKER_HOST", "unix:///var/run/docker.sock")
+
+	lines := []string{}
+	job := Job{
+		Workspace: t.TempDir(),
+		Execution: types.Execution{Trigger: types.Trigger{ActionToRun: "deploy.yml"}},
+	}
+	if err := NewAct().Run(job, func(line string) { lines = append(lines, line) }); err != nil {
+		t.Fatal(err)
+	}
+
+	output := strings.Join(lines, "\n")
+	if strings.Contains(output, "ENCRYPTION_KEYS") {
+		t.Error("act received the env ENCRYPTION_KEYS of the worker")
+	}
+
+	if !strings.Contains(output, "DOCKER_HOST=unix:///var/run/docker.sock") {
+		t.Errorf("expected act to receive DOCKER_HOST, got %s", output)
+	}
+}
+
+func TestScriptWorkspaceIsAbsolute(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pipelines", "execution"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	script := "echo \"$GITHUB_WORKSPACE\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pipelines", "execution", DefaultScript), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	lines := []string{}
+	job := Job{Workspace: filepath.Join("pipelines", "execution")}
+	if err := NewScript().Run(job, func(line string) { lines = append(lines, line) }); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := filepath.Abs(job.Workspace)
+	if len(lines) != 1 || lines[0] != expected {
+		t.Errorf("expected GITHUB_WORKSPACE %s, got %v", expected, lines)
+	}
+}
@@ -0,0 +1,38 @@
+package executor
+
+// Fake is an executor that runs nothing, so the pipeline processing can be
+// exercised without git, act or Docker.
+type Fake struct {
+	Lines        []string
+	PrepareError error
+	RunError     error
+	CleanupError error
+	Jobs         []Job
+	Phases       []string
+}
+
+func NewFake(lines ...string) *Fake {
+	return &Fake{
+		Lines: lines,
+	}
+}
+
+func (f *Fake) Prepare(job Job) error {
+	f.Jobs = append(f.Jobs, job)
+	f.Phases = append(f.Phases, "prepare")
+	return f.PrepareError
+}
+
+func (f *Fake) Run(job Job, onLog LogHandler) error {
+	f.Phases = append(f.Phases, "run")
+	for _, line := range f.Lines {
+		onLog(line)
+	}
+
+	return f.RunError
+}
+
+func (f *Fake) Cleanup(job Job) error {
+	f.Phases = append(f.Phases, "cleanup")
+	return f.CleanupError
+}
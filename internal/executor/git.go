@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tiago123456789/own-githubaction/pkg/github"
+	"github.com/tiago123456789/own-githubaction/pkg/logger"
+)
+
+// askPassScript answers the git prompts with the environment variables of the
+// git process, so the token never is part of the url or the arguments.
+const askPassScript = `#!/bin/sh
+case "$1" in
+Username*) printf '%s\n' "$GIT_ASKPASS_USERNAME" ;;
+*) printf '%s\n' "$GIT_ASKPASS_PASSWORD" ;;
+esac
+`
+
+// githubKnownHosts are the host keys published by Github, so the first clone
+// never trusts an unknown host.
+const githubKnownHosts = `github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl
+github.com ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEmKSENjQEezOmxkZMy7opKgwFB9nkt5YRrYMjNuG5N87uRgg6CLrbo5wAdT/y6v0mKV0U2w0WZ2YB/++Tpockg=
+github.com ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABgQCj7ndNxQowgcQnjshcLrqPEiiphnt+VTTvDP6mHBL9j1aNUkY4Ue1gvwnGLVlOhGeYrnZaMgRK6+PKCUXaDbC7qtbW8gIkhL7aGCsOr/C56SJMy/BCZfxd1nWzAOxSDPgVsmerOBYfNqltV9/hWCqBywINIR+5dIg6JTJ72pcEpEjcYgXkE2YEFXV1JHnsKgbLWNlhScqb2UmyRkQyytRLtL+38TGxkxCflmO+5Z8CSSNY7GidjMIZ7Q4zMjA2n1nGrlTDkzwDCsw+wqFPGQA179cnfGWOWRVruj16z6XyvxvjJwbz0wQZ75XK5tKSb7FNyeIEs4TT4jk+S4dhPeAUC5y+bDYirYgM4GC7uEnztnZyaVWQ7B381AK4Qdrwt51ZqExKbQpTUNn+EjqoTwvqNj4kqx5QUCI0ThS/YkOxJCXmPUWZbhjpCg56i+2aB6CmK2JGhn57K5mj0MNdBXA4/WnwH6XoPWJzK5Nyu2zB3nAZp+S5hpQs+p1vN1/wsjk=
+`
+
+func knownHosts() ([]byte, error) {
+	knownHostsFile := os.Getenv("GIT_SSH_KNOWN_HOSTS")
+	if len(knownHostsFile) == 0 {
+		return []byte(githubKnownHosts), nil
+	}
+
+	return os.ReadFile(knownHostsFile)
+}
+
+func cloneWithDeployKey(job Job) error {
+	owner, repository, err := github.ParseRepository(job.Execution.Trigger.LinkRepository)
+	if err != nil {
+		return err
+	}
+
+	sshDir, err := os.MkdirTemp("", "ssh-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(sshDir)
+
+	deployKey := filepath.Join(sshDir, "deploy_key")
+	if err := os.WriteFile(deployKey, []byte(strings.TrimSpace(job.DeployKey)+"\n"), 0600); err != nil {
+		return err
+	}
+
+	hosts, err := knownHosts()
+	if err != nil {
+		return err
+	}
+
+	knownHostsFile := filepath.Join(sshDir, "known_hosts")
+	if err := os.WriteFile(knownHostsFile, hosts, 0600); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(
+		"git", "clone", "--",
+		fmt.Sprintf("git@github.com:%s/%s.git", owner, repository),
+		job.Workspace,
+	)
+	cmd.Env = pipelineEnv(map[string]string{
+		"GIT_TERMINAL_PROMPT": "0",
+		"GIT_SSH_COMMAND": fmt.Sprintf(
+			"ssh -F /dev/null -i '%s' -o IdentitiesOnly=yes -o UserKnownHostsFile='%s' -o StrictHostKeyChecking=yes",
+			deployKey, knownHostsFile,
+		),
+	})
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func clone(job Job) error {
+	trigger := job.Execution.Trigger
+	if err := github.ValidateRepositoryLink(trigger.LinkRepository); err != nil {
+		return err
+	}
+
+	if len(job.DeployKey) > 0 {
+		return cloneWithDeployKey(job)
+	}
+
+	cmd := exec.Command(
+		"git", "-c", "credential.helper=",
+		"clone", "--", trigger.LinkRepository, job.Workspace,
+	)
+	env := map[string]string{"GIT_TERMINAL_PROMPT": "0"}
+	if len(job.RepositoryToken) > 0 {
+		askPassDir, err := os.MkdirTemp("", "askpass-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(askPassDir)
+
+		askPass := filepath.Join(askPassDir, "askpass.sh")
+		if err := os.WriteFile(askPass, []byte(askPassScript), 0700); err != nil {
+			return err
+		}
+
+		env["GIT_ASKPASS"] = askPass
+		env["GIT_ASKPASS_USERNAME"] = "x-access-token"
+		env["GIT_ASKPASS_PASSWORD"] = job.RepositoryToken
+	}
+	cmd.Env = pipelineEnv(env)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, logger.Scrub(strings.TrimSpace(string(output))))
+	}
+
+	return nil
+}
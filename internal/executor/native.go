@@ -0,0 +1,319 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tiago123456789/own-githubaction/pkg/github"
+	"github.com/tiago123456789/own-githubaction/pkg/workflow"
+)
+
+// Native runs the run steps of the workflow directly on the host, without act
+// and Docker. Steps using actions are not supported, except actions/checkout
+// because the repository is already cloned on the workspace.
+type Native struct {
+}
+
+func NewNative() *Native {
+	return &Native{}
+}
+
+func (n *Native) Prepare(job Job) error {
+	return clone(job)
+}
+
+func (n *Native) Run(job Job, onLog LogHandler) error {
+	if err := workflow.ValidateFileName(job.Execution.Trigger.ActionToRun); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(
+		filepath.Join(job.Workspace, ".github", "workflows", job.Execution.Trigger.ActionToRun),
+	)
+	if err != nil {
+		return err
+	}
+
+	parsedWorkflow, err := workflow.Parse(content)
+	if err != nil {
+		return err
+	}
+
+	order, err := parsedWorkflow.JobsOrder()
+	if err != nil {
+		return err
+	}
+
+	workspace, err := filepath.Abs(job.Workspace)
+	if err != nil {
+		return err
+	}
+
+	results := map[string]string{}
+	failed := false
+	for _, id := range order {
+		workflowJob := parsedWorkflow.Jobs[id]
+		ctx := workflow.Context{
+			Github:    n.githubContext(job, workspace),
+			Env:       mergeEnvs(parsedWorkflow.Env, workflowJob.Env),
+			Secrets:   job.Secrets,
+			Vars:      job.Vars,
+			Runner:    map[string]interface{}{"os": "Linux", "temp": os.TempDir()},
+			Needs:     map[string]interface{}{},
+			JobStatus: "success",
+		}
+
+		for _, need := range workflowJob.Needs {
+			ctx.Needs[need] = map[string]interface{}{"result": results[need]}
+			if results[need] == "failure" {
+				ctx.JobStatus = "failure"
+			} else if results[need] != "success" && ctx.JobStatus == "success" {
+				ctx.JobStatus = "skipped"
+			}
+		}
+
+		shouldRun, err := workflow.EvaluateCondition(workflowJob.If, ctx)
+		if err != nil {
+			onLog(fmt.Sprintf("[%s] Failed to evaluate if: %v", id, err))
+			results[id] = "failure"
+			failed = true
+			continue
+		}
+
+		if !shouldRun {
+			onLog(fmt.Sprintf("[%s] Skipped", id))
+			results[id] = "skipped"
+			continue
+		}
+
+		ctx.JobStatus = "success"
+		results[id] = n.runJob(id, parsedWorkflow, workflowJob, ctx, workspace, onLog)
+		if results[id] == "failure" && !workflowJob.ContinueOnError {
+			failed = true
+		}
+	}
+
+	if failed {
+		return errors.New("one or more jobs failed")
+	}
+
+	return nil
+}
+
+func (n *Native) Cleanup(job Job) error {
+	return os.RemoveAll(job.Workspace)
+}
+
+func (n *Native) githubContext(job Job, workspace string) map[string]interface{} {
+	repository := ""
+	owner, name, err := github.ParseRepository(job.Execution.Trigger.LinkRepository)
+	if err == nil {
+		repository = fmt.Sprintf("%s/%s", owner, name)
+	}
+
+	return map[string]interface{}{
+		"repository": repository,
+		"workspace":  workspace,
+		"run_id":     job.Execution.ID,
+		"ref":        job.Execution.Ref,
+		"event_name": job.Execution.Event,
+	}
+}
+
+func (n *Native) runJob(
+	id string, parsedWorkflow workflow.Workflow, workflowJob workflow.Job,
+	ctx workflow.Context, workspace string, onLog LogHandler,
+) string {
+	jobName := id
+	if len(workflowJob.Name) > 0 {
+		jobName = workflowJob.Name
+	}
+
+	jobEnv, err := interpolateEnvs(ctx.Env, ctx)
+	if err != nil {
+		onLog(fmt.Sprintf("[%s] Failed to evaluate env: %v", jobName, err))
+		return "failure"
+	}
+
+	status := "success"
+	for index, step := range workflowJob.Steps {
+		stepName := step.Name
+		if len(stepName) == 0 {
+			stepName = fmt.Sprintf("step %d", index+1)
+		}
+		prefix := fmt.Sprintf("[%s/%s]", jobName, stepName)
+
+		ctx.JobStatus = status
+		ctx.Env = mergeEnvs(jobEnv, step.Env)
+		shouldRun, err := workflow.EvaluateCondition(step.If, ctx)
+		if err != nil {
+			onLog(fmt.Sprintf("%s Failed to evaluate if: %v", prefix, err))
+			status = "failure"
+			continue
+		}
+
+		if !shouldRun {
+			onLog(fmt.Sprintf("%s Skipped", prefix))
+			continue
+		}
+
+		err = n.runStep(step, parsedWorkflow, workflowJob, ctx, workspace, id, prefix, onLog)
+		if err != nil {
+			onLog(fmt.Sprintf("%s Failed: %v", prefix, err))
+			if !step.ContinueOnError {
+				status = "failure"
+			}
+			continue
+		}
+
+		onLog(fmt.Sprintf("%s Success", prefix))
+	}
+
+	return status
+}
+
+func (n *Native) runStep(
+	step workflow.Step, parsedWorkflow workflow.Workflow, workflowJob workflow.Job,
+	ctx workflow.Context, workspace string, jobId string, prefix string, onLog LogHandler,
+) error {
+	if len(step.Uses) > 0 {
+		if strings.HasPrefix(step.Uses, "actions/checkout@") {
+			onLog(fmt.Sprintf("%s The repository is already checked out", prefix))
+			return nil
+		}
+
+		return fmt.Errorf("the native executor doesn't support uses %s", step.Uses)
+	}
+
+	script, err := workflow.Interpolate(step.Run, ctx)
+	if err != nil {
+		return err
+	}
+
+	env, err := interpolateEnvs(ctx.Env, ctx)
+	if err != nil {
+		return err
+	}
+
+	workingDirectory := firstNotEmpty(
+		step.WorkingDirectory,
+		workflowJob.Defaults.Run.WorkingDirectory,
+		parsedWorkflow.Defaults.Run.WorkingDirectory,
+	)
+	directory, err := workspacePath(workspace, workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	scriptFile, err := os.CreateTemp("", "step-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(scriptFile.Name())
+
+	_, err = scriptFile.WriteString(script)
+	scriptFile.Close()
+	if err != nil {
+		return err
+	}
+
+	shell := firstNotEmpty(
+		step.Shell,
+		workflowJob.Defaults.Run.Shell,
+		parsedWorkflow.Defaults.Run.Shell,
+	)
+	command := shellCommand(shell, scriptFile.Name())
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = directory
+	cmd.Env = pipelineEnv(
+		map[string]string{
+			"CI":                "true",
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_WORKSPACE":  workspace,
+			"GITHUB_JOB":        jobId,
+			"GITHUB_REPOSITORY": fmt.Sprint(ctx.Github["repository"]),
+			"GITHUB_RUN_ID":     fmt.Sprint(ctx.Github["run_id"]),
+		},
+		env,
+	)
+
+	return streamCombinedOutput(cmd, func(line string) {
+		onLog(fmt.Sprintf("%s %s", prefix, line))
+	})
+}
+
+func shellCommand(shell string, scriptFile string) []string {
+	switch shell {
+	case "", "bash":
+		return []string{"bash", "--noprofile", "--norc", "-eo", "pipefail", scriptFile}
+	case "sh":
+		return []string{"sh", "-e", scriptFile}
+	case "python":
+		return []string{"python3", scriptFile}
+	}
+
+	command := strings.Fields(shell)
+	hasPlaceholder := false
+	for index, argument := range command {
+		if strings.Contains(argument, "{0}") {
+			command[index] = strings.ReplaceAll(argument, "{0}", scriptFile)
+			hasPlaceholder = true
+		}
+	}
+
+	if !hasPlaceholder {
+		command = append(command, scriptFile)
+	}
+
+	return command
+}
+
+func workspacePath(workspace string, workingDirectory string) (string, error) {
+	directory := filepath.Join(workspace, workingDirectory)
+	relative, err := filepath.Rel(workspace, directory)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, "../") {
+		return "", fmt.Errorf("the working-directory %s is outside of the workspace", workingDirectory)
+	}
+
+	return directory, nil
+}
+
+func interpolateEnvs(envs map[string]string, ctx workflow.Context) (map[string]string, error) {
+	result := map[string]string{}
+	for key, value := range envs {
+		interpolated, err := workflow.Interpolate(value, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		result[key] = interpolated
+	}
+
+	return result, nil
+}
+
+func mergeEnvs(envs ...map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, env := range envs {
+		for key, value := range env {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+func firstNotEmpty(values ...string) string {
+	for _, value := range values {
+		if len(value) > 0 {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNativeStepDoesntReceiveWorkerEnvs(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEYS", "key1:master")
+	workspace := t.TempDir()
+	workflowDir := filepath.Join(workspace, ".github", "workflows")
+	if err := os.MkdirAll(workflowDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	content := `
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    env:
+      TOKEN: ${{ secrets.TOKEN }}
+    steps:
+      - run: env
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "ci.yml"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{}
+	job := Job{
+		Workspace: workspace,
+		Secrets:   map[string]string{"TOKEN": "job-secret"},
+	}
+	job.Execution.Trigger.ActionToRun = "ci.yml"
+	if err := NewNative().Run(job, func(line string) { lines = append(lines, line) }); err != nil {
+		t.Fatal(err)
+	}
+
+	output := strings.Join(lines, "\n")
+	if strings.Contains(output, "ENCRYPTION_KEYS") {
+		t.Error("the step received the env ENCRYPTION_KEYS of the worker")
+	}
+
+	for _, expected := range []string{"TOKEN=job-secret", "GITHUB_ACTIONS=true", "CI=true"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %s on the env of the step, got %s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/tiago123456789/own-githubaction/pkg/workflow"
+)
+
+const DefaultScript = "ci.sh"
+
+// Script runs a shell script of the repository instead of a workflow, giving the
+// variables and secrets as environment variables.
+type Script struct {
+}
+
+func NewScript() *Script {
+	return &Script{}
+}
+
+func (s *Script) Prepare(job Job) error {
+	return clone(job)
+}
+
+func (s *Script) Run(job Job, onLog LogHandler) error {
+	script := job.Execution.Trigger.ActionToRun
+	if len(script) == 0 {
+		script = DefaultScript
+	}
+
+	if err := workflow.ValidateScriptPath(script); err != nil {
+		return err
+	}
+
+	workspace, err := filepath.Abs(job.Workspace)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("bash", "--", script)
+	cmd.Dir = job.Workspace
+	cmd.Env = pipelineEnv(
+		map[string]string{
+			"CI":               "true",
+			"GITHUB_WORKSPACE": workspace,
+		},
+		job.Vars,
+		job.Secrets,
+	)
+
+	return streamOutput(cmd, onLog)
+}
+
+func (s *Script) Cleanup(job Job) error {
+	return os.RemoveAll(job.Workspace)
+}
@@ -1,18 +1,74 @@
 package middleware
 
 import (
-	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/tiago123456789/own-githubaction/internal/entities"
 )
 
-func HasAuthorization(c *fiber.Ctx) error {
-	apiKey := os.Getenv("API_KEY")
-	if apiKey != c.Get("x-api-key") {
-		return c.Status(403).JSON(fiber.Map{
-			"message": "You don't have permission to do that action",
-		})
+const SessionCookie = "session"
+
+// ProjectParam is the route param of the project the routes belong to.
+const ProjectParam = "projectId"
+
+type Authenticator func(apiKey string, ip string) (entities.User, error)
+
+type PermissionChecker func(user entities.User, permission string, projectId uint, triggerId uint) bool
+
+// HasAuthorization authenticates the user of the x-api-key header, of the api
+// token on the Authorization header or of the session cookie, and checks the
+// permission. An empty permission only requires the user authenticated. The
+// permission is checked on the project of the projectId route param, and when
+// triggerParam is set on the trigger of that route param, so the members of
+// the trigger are allowed too.
+func HasAuthorization(
+	authenticate Authenticator, can PermissionChecker, permission string, triggerParam string,
+) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apiKey := c.Get("x-api-key")
+		if len(apiKey) == 0 {
+			apiKey = strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+		}
+
+		if len(apiKey) == 0 {
+			apiKey = c.Cookies(SessionCookie)
+		}
+
+		user, err := authenticate(apiKey, c.IP())
+		if err != nil {
+			return c.Status(401).JSON(fiber.Map{
+				"message": "You need to be authenticated to do that action",
+			})
+		}
+
+		projectId := 0
+		if len(c.Params(ProjectParam)) > 0 {
+			projectId, err = strconv.Atoi(c.Params(ProjectParam))
+			if err != nil {
+				projectId = -1
+			}
+		}
+
+		triggerId := 0
+		if len(triggerParam) > 0 {
+			triggerId, _ = strconv.Atoi(c.Params(triggerParam))
+		}
+
+		if len(permission) > 0 &&
+			(projectId < 0 || triggerId < 0 || !can(user, permission, uint(projectId), uint(triggerId))) {
+			return c.Status(403).JSON(fiber.Map{
+				"message": "You don't have permission to do that action",
+			})
+		}
+
+		c.Locals("user", user)
+		return c.Next()
 	}
+}
 
-	return c.Next()
+func GetUser(c *fiber.Ctx) entities.User {
+	user, _ := c.Locals("user").(entities.User)
+	return user
 }
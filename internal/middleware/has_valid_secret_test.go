@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func sign(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHasValidSecret(t *testing.T) {
+	secrets := map[string][]string{
+		"hash":   {"new-secret", "previous-secret"},
+		"legacy": {"legacy"},
+	}
+	app := fiber.New()
+	app.Post("/triggers-execute/:hash", HasValidSecret(func(hash string) []string {
+		return secrets[hash]
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(202)
+	})
+
+	body := `{"ref": "refs/heads/main"}`
+	tests := []struct {
+		name      string
+		hash      string
+		signature string
+		status    int
+	}{
+		{"current secret", "hash", sign("new-secret", body), 202},
+		{"previous secret", "hash", sign("previous-secret", body), 202},
+		{"legacy hash", "legacy", sign("legacy", body), 202},
+		{"other secret", "hash", sign("other-secret", body), 403},
+		{"other body", "hash", sign("new-secret", body+" "), 403},
+		{"unknown trigger", "unknown", sign("new-secret", body), 403},
+		{"without signature", "hash", "", 403},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest("POST", "/triggers-execute/"+test.hash, strings.NewReader(body))
+		if len(test.signature) > 0 {
+			request.Header.Set("X-Hub-Signature-256", test.signature)
+		}
+
+		response, err := app.Test(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.StatusCode != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, response.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type IApiTokenRepository interface {
+	FindByUserId(userId uint) []entities.ApiToken
+	FindById(id uint) entities.ApiToken
+	FindByHash(tokenHash string) entities.ApiToken
+	Save(data *entities.ApiToken)
+	UpdateLastUsedAt(token *entities.ApiToken, lastUsedAt time.Time)
+	Delete(token *entities.ApiToken)
+}
+
+type ApiTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewApiTokenRepository(
+	db *gorm.DB,
+) *ApiTokenRepository {
+	return &ApiTokenRepository{
+		db: db,
+	}
+}
+
+func (a *ApiTokenRepository) FindByUserId(userId uint) []entities.ApiToken {
+	var tokens []entities.ApiToken
+	a.db.Order("created_at desc").Find(&tokens, "user_id = ?", userId)
+	return tokens
+}
+
+func (a *ApiTokenRepository) FindById(id uint) entities.ApiToken {
+	var token entities.ApiToken
+	a.db.First(&token, "id = ?", id)
+	return token
+}
+
+func (a *ApiTokenRepository) FindByHash(tokenHash string) entities.ApiToken {
+	var token entities.ApiToken
+	a.db.First(&token, "token_hash = ?", tokenHash)
+	return token
+}
+
+func (a *ApiTokenRepository) Save(data *entities.ApiToken) {
+	a.db.Create(data)
+}
+
+func (a *ApiTokenRepository) UpdateLastUsedAt(token *entities.ApiToken, lastUsedAt time.Time) {
+	a.db.Model(token).Update("last_used_at", lastUsedAt)
+}
+
+func (a *ApiTokenRepository) Delete(token *entities.ApiToken) {
+	a.db.Delete(token)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+	"gorm.io/gorm"
+)
+
+// IAuditRepository doesn't have update or delete, the audit events are
+// append-only.
+type IAuditRepository interface {
+	Save(data *entities.AuditEvent)
+	Find(filter types.AuditFilter, offset int, limit int) ([]entities.AuditEvent, int64)
+	FindInBatches(filter types.AuditFilter, handle func(events []entities.AuditEvent) error) error
+}
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(
+	db *gorm.DB,
+) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// ProtectAuditEvents makes the database refuse to update or delete the audit
+// events, even outside of the api.
+func ProtectAuditEvents(db *gorm.DB) {
+	db.Exec(`CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+		BEGIN SELECT RAISE(ABORT, 'audit events are append-only'); END`)
+	db.Exec(`CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+		BEGIN SELECT RAISE(ABORT, 'audit events are append-only'); END`)
+}
+
+func (a *AuditRepository) Save(data *entities.AuditEvent) {
+	a.db.Create(data)
+}
+
+func (a *AuditRepository) filtered(filter types.AuditFilter) *gorm.DB {
+	query := a.db.Model(&entities.AuditEvent{})
+	if len(filter.Action) > 0 {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if len(filter.Actor) > 0 {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+
+	if filter.TriggerId != 0 {
+		query = query.Where("trigger_id = ?", filter.TriggerId)
+	}
+
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	return query
+}
+
+func (a *AuditRepository) Find(
+	filter types.AuditFilter, offset int, limit int,
+) ([]entities.AuditEvent, int64) {
+	var events []entities.AuditEvent
+	var total int64
+	a.filtered(filter).Count(&total)
+	a.filtered(filter).Order("id desc").Offset(offset).Limit(limit).Find(&events)
+	return events, total
+}
+
+func (a *AuditRepository) FindInBatches(
+	filter types.AuditFilter, handle func(events []entities.AuditEvent) error,
+) error {
+	var events []entities.AuditEvent
+	return a.filtered(filter).Order("id asc").FindInBatches(&events, 500, func(tx *gorm.DB, batch int) error {
+		return handle(events)
+	}).Error
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type IProjectRepository interface {
+	FindAll() []entities.Project
+	FindById(id uint) entities.Project
+	FindByName(name string) entities.Project
+	FindByUserId(userId uint) []entities.Project
+	Save(data *entities.Project)
+	FindMembers(projectId uint) []entities.ProjectMember
+	FindMember(projectId uint, userId uint) entities.ProjectMember
+	SaveMember(data *entities.ProjectMember)
+	DeleteMember(member *entities.ProjectMember)
+}
+
+type ProjectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(
+	db *gorm.DB,
+) *ProjectRepository {
+	return &ProjectRepository{
+		db: db,
+	}
+}
+
+// MigrateToProjects moves the triggers, secret groups, organisation secrets and
+// environments created before the projects to the "default" project, and adds
+// the users existing at that moment as its members.
+func MigrateToProjects(db *gorm.DB) {
+	if db.Migrator().HasIndex(&entities.SecretGroup{}, "idx_secret_groups_name") {
+		db.Migrator().DropIndex(&entities.SecretGroup{}, "idx_secret_groups_name")
+	}
+
+	var project entities.Project
+	db.First(&project, "name = ?", "default")
+	if project.ID == 0 {
+		project = entities.Project{Name: "default", SecretPrefix: "org"}
+		db.Create(&project)
+
+		var users []entities.User
+		db.Find(&users)
+		for _, user := range users {
+			db.Create(&entities.ProjectMember{ProjectId: project.ID, UserId: user.ID})
+		}
+	}
+
+	db.Model(&entities.Trigger{}).Unscoped().
+		Where("COALESCE(project_id, 0) = 0").Update("project_id", project.ID)
+	db.Model(&entities.SecretGroup{}).Unscoped().
+		Where("COALESCE(project_id, 0) = 0").Update("project_id", project.ID)
+	db.Model(&entities.Secret{}).Unscoped().
+		Where("COALESCE(project_id, 0) = 0 AND trigger_id = 0 AND group_id = 0").Update("project_id", project.ID)
+	db.Model(&entities.SecretAudit{}).Unscoped().
+		Where("COALESCE(project_id, 0) = 0 AND trigger_id = 0 AND group_id = 0").Update("project_id", project.ID)
+	db.Model(&entities.Variable{}).Unscoped().
+		Where("COALESCE(project_id, 0) = 0 AND trigger_id = 0").Update("project_id", project.ID)
+	db.Model(&entities.VariableHistory{}).Unscoped().
+		Where("COALESCE(project_id, 0) = 0 AND trigger_id = 0").Update("project_id", project.ID)
+}
+
+func (p *ProjectRepository) FindAll() []entities.Project {
+	var projects []entities.Project
+	p.db.Order("name asc").Find(&projects)
+	return projects
+}
+
+func (p *ProjectRepository) FindById(id uint) entities.Project {
+	var project entities.Project
+	p.db.First(&project, "id = ?", id)
+	return project
+}
+
+func (p *ProjectRepository) FindByName(name string) entities.Project {
+	var project entities.Project
+	p.db.First(&project, "name = ?", name)
+	return project
+}
+
+func (p *ProjectRepository) FindByUserId(userId uint) []entities.Project {
+	var projects []entities.Project
+	p.db.Order("name asc").
+		Where("id IN (?)", p.db.Model(&entities.ProjectMember{}).
+			Select("project_id").Where("user_id = ?", userId)).
+		Find(&projects)
+	return projects
+}
+
+func (p *ProjectRepository) Save(data *entities.Project) {
+	p.db.Create(data)
+}
+
+func (p *ProjectRepository) FindMembers(projectId uint) []entities.ProjectMember {
+	var members []entities.ProjectMember
+	p.db.Order("user_id asc").Find(&members, "project_id = ?", projectId)
+	return members
+}
+
+func (p *ProjectRepository) FindMember(projectId uint, userId uint) entities.ProjectMember {
+	var member entities.ProjectMember
+	p.db.First(&member, "project_id = ? AND user_id = ?", projectId, userId)
+	return member
+}
+
+func (p *ProjectRepository) SaveMember(data *entities.ProjectMember) {
+	p.db.Save(data)
+}
+
+func (p *ProjectRepository) DeleteMember(member *entities.ProjectMember) {
+	p.db.Unscoped().Delete(member)
+}
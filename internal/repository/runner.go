@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type IRunnerRepository interface {
+	FindAll() []entities.Runner
+	SaveByName(data *entities.Runner)
+}
+
+type RunnerRepository struct {
+	db *gorm.DB
+}
+
+func NewRunnerRepository(
+	db *gorm.DB,
+) *RunnerRepository {
+	return &RunnerRepository{
+		db: db,
+	}
+}
+
+func (r *RunnerRepository) FindAll() []entities.Runner {
+	var runners []entities.Runner
+	r.db.Order("last_seen_at desc").Find(&runners)
+	return runners
+}
+
+func (r *RunnerRepository) SaveByName(data *entities.Runner) {
+	r.db.Where(entities.Runner{Name: data.Name}).Assign(*data).FirstOrCreate(data)
+}
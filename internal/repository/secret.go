@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type ISecretRepository interface {
+	FindCurrent(triggerId uint, groupId uint, projectId uint) []entities.Secret
+	FindLastVersion(triggerId uint, groupId uint, projectId uint, key string) int
+	Save(data *entities.Secret)
+	UpdatePolicy(secret *entities.Secret, policy entities.Secret)
+	DeleteByKey(triggerId uint, groupId uint, projectId uint, key string) []entities.Secret
+	SaveAudit(data *entities.SecretAudit)
+	FindAudits(triggerId uint, groupId uint, projectId uint) []entities.SecretAudit
+}
+
+type SecretRepository struct {
+	db *gorm.DB
+}
+
+func NewSecretRepository(
+	db *gorm.DB,
+) *SecretRepository {
+	return &SecretRepository{
+		db: db,
+	}
+}
+
+func (s *SecretRepository) FindCurrent(triggerId uint, groupId uint, projectId uint) []entities.Secret {
+	var secrets []entities.Secret
+	s.db.Order("key asc, version desc").
+		Find(&secrets, "trigger_id = ? AND group_id = ? AND project_id = ?", triggerId, groupId, projectId)
+
+	current := []entities.Secret{}
+	for _, secret := range secrets {
+		if len(current) > 0 && current[len(current)-1].Key == secret.Key {
+			continue
+		}
+		current = append(current, secret)
+	}
+
+	return current
+}
+
+// FindLastVersion includes the deleted versions, so a key created again never
+// reuses the name of an old version on the secret manager.
+func (s *SecretRepository) FindLastVersion(triggerId uint, groupId uint, projectId uint, key string) int {
+	var version int
+	s.db.Unscoped().Model(&entities.Secret{}).
+		Where("trigger_id = ? AND group_id = ? AND project_id = ? AND key = ?", triggerId, groupId, projectId, key).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version)
+
+	return version
+}
+
+func (s *SecretRepository) Save(data *entities.Secret) {
+	s.db.Create(data)
+}
+
+func (s *SecretRepository) UpdatePolicy(secret *entities.Secret, policy entities.Secret) {
+	s.db.Model(secret).Select("branches", "events", "environments").Updates(policy)
+}
+
+func (s *SecretRepository) DeleteByKey(triggerId uint, groupId uint, projectId uint, key string) []entities.Secret {
+	var secrets []entities.Secret
+	s.db.Find(&secrets, "trigger_id = ? AND group_id = ? AND project_id = ? AND key = ?", triggerId, groupId, projectId, key)
+	if len(secrets) > 0 {
+		s.db.Delete(&secrets)
+	}
+
+	return secrets
+}
+
+func (s *SecretRepository) SaveAudit(data *entities.SecretAudit) {
+	s.db.Create(data)
+}
+
+func (s *SecretRepository) FindAudits(triggerId uint, groupId uint, projectId uint) []entities.SecretAudit {
+	var audits []entities.SecretAudit
+	s.db.Order("created_at desc").
+		Find(&audits, "trigger_id = ? AND group_id = ? AND project_id = ?", triggerId, groupId, projectId)
+	return audits
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type ISecretGroupRepository interface {
+	FindAll(projectId uint) []entities.SecretGroup
+	FindById(id uint) entities.SecretGroup
+	FindByNames(projectId uint, names []string) []entities.SecretGroup
+	Save(data *entities.SecretGroup)
+	UpdateAllowedTriggers(group *entities.SecretGroup, allowedTriggers string)
+}
+
+type SecretGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewSecretGroupRepository(
+	db *gorm.DB,
+) *SecretGroupRepository {
+	return &SecretGroupRepository{
+		db: db,
+	}
+}
+
+func (s *SecretGroupRepository) FindAll(projectId uint) []entities.SecretGroup {
+	var groups []entities.SecretGroup
+	s.db.Order("name asc").Find(&groups, "project_id = ?", projectId)
+	return groups
+}
+
+func (s *SecretGroupRepository) FindById(id uint) entities.SecretGroup {
+	var group entities.SecretGroup
+	s.db.First(&group, "id = ?", id)
+	return group
+}
+
+func (s *SecretGroupRepository) FindByNames(projectId uint, names []string) []entities.SecretGroup {
+	var groups []entities.SecretGroup
+	if len(names) == 0 {
+		return groups
+	}
+
+	s.db.Find(&groups, "project_id = ? AND name IN ?", projectId, names)
+	return groups
+}
+
+func (s *SecretGroupRepository) Save(data *entities.SecretGroup) {
+	s.db.Create(data)
+}
+
+func (s *SecretGroupRepository) UpdateAllowedTriggers(
+	group *entities.SecretGroup, allowedTriggers string,
+) {
+	s.db.Model(group).Update("allowed_triggers", allowedTriggers)
+}
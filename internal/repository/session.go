@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type ISessionRepository interface {
+	FindByHash(tokenHash string) entities.Session
+	Save(data *entities.Session)
+	Delete(session *entities.Session)
+	DeleteExpired(now time.Time)
+}
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(
+	db *gorm.DB,
+) *SessionRepository {
+	return &SessionRepository{
+		db: db,
+	}
+}
+
+func (s *SessionRepository) FindByHash(tokenHash string) entities.Session {
+	var session entities.Session
+	s.db.First(&session, "token_hash = ?", tokenHash)
+	return session
+}
+
+func (s *SessionRepository) Save(data *entities.Session) {
+	s.db.Create(data)
+}
+
+func (s *SessionRepository) Delete(session *entities.Session) {
+	s.db.Unscoped().Delete(session)
+}
+
+func (s *SessionRepository) DeleteExpired(now time.Time) {
+	s.db.Unscoped().Where("expires_at < ?", now).Delete(&entities.Session{})
+}
@@ -7,17 +7,27 @@ import (
 
 type ITriggerRepository interface {
 	FindAll() []entities.Trigger
+	FindByProjectId(projectId uint) []entities.Trigger
 	FindExecutionsByTriggerId(triggerId string) []entities.Execution
 	GetExecutionLogsByTriggerIdAndExecutionId(
 		triggerId string, exeuctionId string,
 	) []entities.ExecutionLog
 	Save(data *entities.Trigger)
 	FindByHash(hash string) entities.Trigger
+	FindById(id uint) entities.Trigger
+	UpdateTriggerData(
+		trigger *entities.Trigger, dataModified entities.Trigger,
+	)
+	UpdateSettings(trigger *entities.Trigger, data entities.Trigger)
+	UpdateHasEnvs(trigger *entities.Trigger, hasEnvs bool)
+	Delete(trigger *entities.Trigger)
+	UpdateSecretGroups(trigger *entities.Trigger, secretGroups string)
 	SaveExecution(data *entities.Execution)
 	FindExecutionById(id string) entities.Execution
 	UpdateExecutionData(
 		execution *entities.Execution, dataModified entities.Execution,
 	)
+	IncrementDeferrals(execution *entities.Execution) int
 	SaveExecutionLog(executionLog *entities.ExecutionLog)
 }
 
@@ -25,6 +35,26 @@ type TriggerRepository struct {
 	db *gorm.DB
 }
 
+// TriggersWithRepositoryToken returns the ids of the triggers created before
+// the Github App that still have a long-lived repository token.
+func TriggersWithRepositoryToken(db *gorm.DB) []uint {
+	ids := []uint{}
+	if !db.Migrator().HasColumn(&entities.Trigger{}, "repository_token") {
+		return ids
+	}
+
+	db.Model(&entities.Trigger{}).Where("repository_token <> ''").Pluck("id", &ids)
+	return ids
+}
+
+// DropRepositoryTokens removes the column of the long-lived repository tokens,
+// call it only after the triggers of TriggersWithRepositoryToken were handled.
+func DropRepositoryTokens(db *gorm.DB) {
+	if db.Migrator().HasColumn(&entities.Trigger{}, "repository_token") {
+		db.Migrator().DropColumn(&entities.Trigger{}, "repository_token")
+	}
+}
+
 func NewTriggerRepository(
 	db *gorm.DB,
 ) *TriggerRepository {
@@ -33,6 +63,8 @@ func NewTriggerRepository(
 	}
 }
 
+// FindAll returns the triggers of every project, only for the maintenance
+// tasks. The api lists the triggers of one project with FindByProjectId.
 func (t *TriggerRepository) FindAll() []entities.Trigger {
 	var registers []entities.Trigger
 	t.db.Find(&registers)
@@ -40,6 +72,13 @@ func (t *TriggerRepository) FindAll() []entities.Trigger {
 	return registers
 }
 
+func (t *TriggerRepository) FindByProjectId(projectId uint) []entities.Trigger {
+	var registers []entities.Trigger
+	t.db.Find(&registers, "project_id = ?", projectId)
+
+	return registers
+}
+
 func (t *TriggerRepository) FindExecutionsByTriggerId(triggerId string) []entities.Execution {
 	var executions []entities.Execution
 	t.db.Order("created_at desc").Find(&executions, "trigger_id = ?", triggerId)
@@ -50,7 +89,13 @@ func (t *TriggerRepository) GetExecutionLogsByTriggerIdAndExecutionId(
 	triggerId string, exeuctionId string,
 ) []entities.ExecutionLog {
 	var executionsLogs []entities.ExecutionLog
-	t.db.Order("created_at asc").Find(&executionsLogs, "execution_id = ?", exeuctionId)
+	t.db.Order("execution_logs.created_at asc").
+		Joins("JOIN executions ON executions.id = execution_logs.execution_id").
+		Find(
+			&executionsLogs,
+			"execution_logs.execution_id = ? AND executions.trigger_id = ?",
+			exeuctionId, triggerId,
+		)
 	return executionsLogs
 }
 
@@ -66,6 +111,42 @@ func (t *TriggerRepository) FindByHash(hash string) entities.Trigger {
 	return trigger
 }
 
+func (t *TriggerRepository) FindById(id uint) entities.Trigger {
+	var trigger entities.Trigger
+
+	t.db.First(&trigger, "id = ?", id)
+
+	return trigger
+}
+
+func (t *TriggerRepository) UpdateTriggerData(
+	trigger *entities.Trigger, dataModified entities.Trigger,
+) {
+	t.db.Model(trigger).Updates(dataModified)
+}
+
+// UpdateSettings updates the fields the api can change, including the empty
+// values that UpdateTriggerData skips.
+func (t *TriggerRepository) UpdateSettings(trigger *entities.Trigger, data entities.Trigger) {
+	t.db.Model(trigger).Select(
+		"action_to_run", "labels", "resource_memory_mb", "resource_cpus", "resource_disk_mb", "environment",
+	).Updates(data)
+}
+
+// Delete soft deletes the trigger, so the executions and the audit events
+// keep the reference to it.
+func (t *TriggerRepository) Delete(trigger *entities.Trigger) {
+	t.db.Delete(trigger)
+}
+
+func (t *TriggerRepository) UpdateHasEnvs(trigger *entities.Trigger, hasEnvs bool) {
+	t.db.Model(trigger).Update("has_envs", hasEnvs)
+}
+
+func (t *TriggerRepository) UpdateSecretGroups(trigger *entities.Trigger, secretGroups string) {
+	t.db.Model(trigger).Update("secret_groups", secretGroups)
+}
+
 func (t *TriggerRepository) SaveExecution(data *entities.Execution) {
 	t.db.Create(data)
 }
@@ -82,6 +163,13 @@ func (t *TriggerRepository) UpdateExecutionData(
 	t.db.Model(execution).Updates(dataModified)
 }
 
+// IncrementDeferrals counts one more deferral of the execution, returning the
+// deferrals of every job process.
+func (t *TriggerRepository) IncrementDeferrals(execution *entities.Execution) int {
+	t.db.Model(execution).UpdateColumn("deferrals", gorm.Expr("deferrals + 1"))
+	return t.FindExecutionById(execution.ID).Deferrals
+}
+
 func (t *TriggerRepository) SaveExecutionLog(executionLog *entities.ExecutionLog) {
 	t.db.Save(&executionLog)
 }
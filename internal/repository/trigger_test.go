@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(
+		sqlite.Open(filepath.Join(t.TempDir(), "database")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// legacyTrigger is the trigger before the Github App, with the repository token.
+type legacyTrigger struct {
+	entities.Trigger
+	RepositoryToken string
+}
+
+func (legacyTrigger) TableName() string {
+	return "triggers"
+}
+
+func TestDropRepositoryTokens(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&legacyTrigger{}); err != nil {
+		t.Fatal(err)
+	}
+	withToken := legacyTrigger{Trigger: entities.Trigger{Hash: "a"}, RepositoryToken: "encrypted"}
+	db.Create(&withToken)
+	db.Create(&legacyTrigger{Trigger: entities.Trigger{Hash: "b"}})
+
+	ids := TriggersWithRepositoryToken(db)
+	if len(ids) != 1 || ids[0] != withToken.ID {
+		t.Errorf("expected the trigger %d with repository token, got %v", withToken.ID, ids)
+	}
+
+	if !db.Migrator().HasColumn(&entities.Trigger{}, "repository_token") {
+		t.Error("expected the column repository_token kept until it is dropped")
+	}
+
+	DropRepositoryTokens(db)
+	if db.Migrator().HasColumn(&entities.Trigger{}, "repository_token") {
+		t.Error("expected the column repository_token dropped")
+	}
+
+	var triggers []entities.Trigger
+	db.Find(&triggers)
+	if len(triggers) != 2 {
+		t.Errorf("expected the triggers kept, got %d", len(triggers))
+	}
+
+	if ids := TriggersWithRepositoryToken(db); len(ids) != 0 {
+		t.Errorf("expected no trigger with repository token on the next start, got %v", ids)
+	}
+	DropRepositoryTokens(db)
+}
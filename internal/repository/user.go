@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type IUserRepository interface {
+	FindAll() []entities.User
+	FindById(id uint) entities.User
+	FindByName(name string) entities.User
+	FindByApiKeyHash(apiKeyHash string) entities.User
+	FindByOidcSubject(subject string) entities.User
+	Save(data *entities.User)
+	UpdateRole(user *entities.User, role string)
+	UpdateApiKeyHash(user *entities.User, apiKeyHash string)
+	UpdateDisabled(user *entities.User, disabled bool)
+	DeleteCredentials(user *entities.User)
+	Delete(user *entities.User)
+	FindMembers(triggerId uint) []entities.TriggerMember
+	FindMember(triggerId uint, userId uint) entities.TriggerMember
+	SaveMember(data *entities.TriggerMember)
+	DeleteMember(member *entities.TriggerMember)
+}
+
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(
+	db *gorm.DB,
+) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+func (u *UserRepository) FindAll() []entities.User {
+	var users []entities.User
+	u.db.Order("name asc").Find(&users)
+	return users
+}
+
+func (u *UserRepository) FindById(id uint) entities.User {
+	var user entities.User
+	u.db.First(&user, "id = ?", id)
+	return user
+}
+
+func (u *UserRepository) FindByName(name string) entities.User {
+	var user entities.User
+	u.db.First(&user, "name = ?", name)
+	return user
+}
+
+func (u *UserRepository) FindByApiKeyHash(apiKeyHash string) entities.User {
+	var user entities.User
+	u.db.First(&user, "api_key_hash = ?", apiKeyHash)
+	return user
+}
+
+func (u *UserRepository) FindByOidcSubject(subject string) entities.User {
+	var user entities.User
+	u.db.First(&user, "oidc_subject = ?", subject)
+	return user
+}
+
+func (u *UserRepository) Save(data *entities.User) {
+	u.db.Create(data)
+}
+
+func (u *UserRepository) UpdateRole(user *entities.User, role string) {
+	u.db.Model(user).Update("role", role)
+}
+
+func (u *UserRepository) UpdateApiKeyHash(user *entities.User, apiKeyHash string) {
+	u.db.Model(user).Update("api_key_hash", apiKeyHash)
+}
+
+func (u *UserRepository) UpdateDisabled(user *entities.User, disabled bool) {
+	u.db.Model(user).Update("disabled", disabled)
+}
+
+// DeleteCredentials ends the sessions and revokes the api tokens of the user.
+func (u *UserRepository) DeleteCredentials(user *entities.User) {
+	u.db.Unscoped().Where("user_id = ?", user.ID).Delete(&entities.ApiToken{})
+	u.db.Unscoped().Where("user_id = ?", user.ID).Delete(&entities.Session{})
+}
+
+func (u *UserRepository) Delete(user *entities.User) {
+	// Unscoped, so the name of the user deleted can be used again.
+	u.db.Unscoped().Where("user_id = ?", user.ID).Delete(&entities.TriggerMember{})
+	u.db.Unscoped().Where("user_id = ?", user.ID).Delete(&entities.ProjectMember{})
+	u.db.Unscoped().Where("user_id = ?", user.ID).Delete(&entities.ApiToken{})
+	u.db.Unscoped().Where("user_id = ?", user.ID).Delete(&entities.Session{})
+	u.db.Unscoped().Delete(user)
+}
+
+func (u *UserRepository) FindMembers(triggerId uint) []entities.TriggerMember {
+	var members []entities.TriggerMember
+	u.db.Order("user_id asc").Find(&members, "trigger_id = ?", triggerId)
+	return members
+}
+
+func (u *UserRepository) FindMember(triggerId uint, userId uint) entities.TriggerMember {
+	var member entities.TriggerMember
+	u.db.First(&member, "trigger_id = ? AND user_id = ?", triggerId, userId)
+	return member
+}
+
+func (u *UserRepository) SaveMember(data *entities.TriggerMember) {
+	u.db.Save(data)
+}
+
+func (u *UserRepository) DeleteMember(member *entities.TriggerMember) {
+	u.db.Unscoped().Delete(member)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"gorm.io/gorm"
+)
+
+type IVariableRepository interface {
+	Find(triggerId uint, projectId uint, environment string) []entities.Variable
+	FindByKey(triggerId uint, projectId uint, environment string, key string) entities.Variable
+	Save(data *entities.Variable)
+	Delete(data *entities.Variable)
+	SaveHistory(data *entities.VariableHistory)
+	FindHistory(triggerId uint, projectId uint, environment string) []entities.VariableHistory
+}
+
+type VariableRepository struct {
+	db *gorm.DB
+}
+
+func NewVariableRepository(
+	db *gorm.DB,
+) *VariableRepository {
+	return &VariableRepository{
+		db: db,
+	}
+}
+
+func (v *VariableRepository) Find(triggerId uint, projectId uint, environment string) []entities.Variable {
+	var variables []entities.Variable
+	v.db.Order("key asc").
+		Find(&variables, "trigger_id = ? AND project_id = ? AND environment = ?", triggerId, projectId, environment)
+	return variables
+}
+
+func (v *VariableRepository) FindByKey(triggerId uint, projectId uint, environment string, key string) entities.Variable {
+	var variable entities.Variable
+	v.db.First(&variable, "trigger_id = ? AND project_id = ? AND environment = ? AND key = ?", triggerId, projectId, environment, key)
+	return variable
+}
+
+func (v *VariableRepository) Save(data *entities.Variable) {
+	v.db.Save(data)
+}
+
+func (v *VariableRepository) Delete(data *entities.Variable) {
+	v.db.Delete(data)
+}
+
+func (v *VariableRepository) SaveHistory(data *entities.VariableHistory) {
+	v.db.Create(data)
+}
+
+func (v *VariableRepository) FindHistory(triggerId uint, projectId uint, environment string) []entities.VariableHistory {
+	var history []entities.VariableHistory
+	v.db.Order("created_at desc").
+		Find(&history, "trigger_id = ? AND project_id = ? AND environment = ?", triggerId, projectId, environment)
+	return history
+}
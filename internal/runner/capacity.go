@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"sync"
+
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+type Capacity struct {
+	Cpus         int
+	Load         float64
+	FreeMemoryMb int
+	FreeDiskMb   int
+}
+
+// Fits compares only the resources the trigger requests, so a busy host, or
+// one whose reservations went over the free values, still runs the triggers
+// that don't request resources.
+func (c Capacity) Fits(resources types.Resources) bool {
+	if resources.MemoryMb > 0 && resources.MemoryMb > c.FreeMemoryMb {
+		return false
+	}
+
+	if resources.DiskMb > 0 && resources.DiskMb > c.FreeDiskMb {
+		return false
+	}
+
+	if resources.Cpus > 0 && resources.Cpus > float64(c.Cpus)-c.Load {
+		return false
+	}
+
+	return true
+}
+
+type IAdmission interface {
+	Capacity() (Capacity, error)
+	Running() int
+	Admit(resources types.Resources) (func(), bool, error)
+}
+
+// Admission keeps the resources of the executions already admitted by this
+// worker reserved, because the host only shows their real usage some time after
+// they start.
+type Admission struct {
+	dir      string
+	mutex    sync.Mutex
+	reserved types.Resources
+	running  int
+}
+
+func NewAdmission(dir string) *Admission {
+	return &Admission{
+		dir: dir,
+	}
+}
+
+func (a *Admission) Capacity() (Capacity, error) {
+	return readCapacity(a.dir)
+}
+
+func (a *Admission) Running() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.running
+}
+
+func (a *Admission) Admit(resources types.Resources) (func(), bool, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	capacity, err := readCapacity(a.dir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	capacity.FreeMemoryMb -= a.reserved.MemoryMb
+	capacity.FreeDiskMb -= a.reserved.DiskMb
+	capacity.Load += a.reserved.Cpus
+	if !capacity.Fits(resources) {
+		return nil, false, nil
+	}
+
+	a.reserved.MemoryMb += resources.MemoryMb
+	a.reserved.DiskMb += resources.DiskMb
+	a.reserved.Cpus += resources.Cpus
+	a.running++
+
+	release := func() {
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+
+		a.reserved.MemoryMb -= resources.MemoryMb
+		a.reserved.DiskMb -= resources.DiskMb
+		a.reserved.Cpus -= resources.Cpus
+		a.running--
+	}
+
+	return release, true, nil
+}
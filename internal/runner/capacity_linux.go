@@ -0,0 +1,74 @@
+//go:build linux
+
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func readCapacity(dir string) (Capacity, error) {
+	capacity := Capacity{Cpus: runtime.NumCPU()}
+
+	loadavg, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return capacity, err
+	}
+
+	fields := strings.Fields(string(loadavg))
+	if len(fields) == 0 {
+		return capacity, errors.New("unexpected /proc/loadavg format")
+	}
+
+	capacity.Load, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return capacity, err
+	}
+
+	capacity.FreeMemoryMb, err = readAvailableMemoryMb()
+	if err != nil {
+		return capacity, err
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return capacity, err
+	}
+	capacity.FreeDiskMb = int(stat.Bavail * uint64(stat.Bsize) / 1024 / 1024)
+
+	return capacity, nil
+}
+
+func readAvailableMemoryMb() (int, error) {
+	meminfo, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer meminfo.Close()
+
+	scanner := bufio.NewScanner(meminfo)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+
+		availableKb, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return 0, err
+		}
+
+		return availableKb / 1024, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, errors.New("MemAvailable not found in /proc/meminfo")
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package runner
+
+import "errors"
+
+func readCapacity(dir string) (Capacity, error) {
+	return Capacity{}, errors.New("reading the host capacity is only supported on linux")
+}
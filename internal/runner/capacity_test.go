@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+func TestCapacityFits(t *testing.T) {
+	busy := Capacity{Cpus: 2, Load: 3.5, FreeMemoryMb: 1024, FreeDiskMb: 2048}
+	overReserved := Capacity{Cpus: 4, Load: 1, FreeMemoryMb: -256, FreeDiskMb: -512}
+	tests := []struct {
+		capacity  Capacity
+		resources types.Resources
+		fits      bool
+	}{
+		{busy, types.Resources{}, true},
+		{busy, types.Resources{MemoryMb: 512, DiskMb: 1024}, true},
+		{busy, types.Resources{Cpus: 0.5}, false},
+		{busy, types.Resources{MemoryMb: 2048}, false},
+		{busy, types.Resources{DiskMb: 4096}, false},
+		{overReserved, types.Resources{}, true},
+		{overReserved, types.Resources{Cpus: 2}, true},
+		{overReserved, types.Resources{MemoryMb: 1}, false},
+		{overReserved, types.Resources{DiskMb: 1}, false},
+	}
+
+	for _, test := range tests {
+		if fits := test.capacity.Fits(test.resources); fits != test.fits {
+			t.Errorf("%+v.Fits(%+v) = %v, expected %v", test.capacity, test.resources, fits, test.fits)
+		}
+	}
+}
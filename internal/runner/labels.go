@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const MaxLabels = 8
+
+const queuePrefix = "runner:"
+
+func ParseLabels(value string) []string {
+	return NormalizeLabels(strings.Split(value, ","))
+}
+
+func NormalizeLabels(values []string) []string {
+	unique := map[string]bool{}
+	labels := []string{}
+	for _, value := range values {
+		label := strings.ToLower(strings.TrimSpace(value))
+		if len(label) == 0 || unique[label] {
+			continue
+		}
+
+		unique[label] = true
+		labels = append(labels, label)
+	}
+
+	sort.Strings(labels)
+	return labels
+}
+
+// LabelsError is the error of labels that can't route the executions to the
+// job processes.
+type LabelsError struct {
+	message string
+}
+
+func (e LabelsError) Error() string {
+	return e.message
+}
+
+// ValidateLabels refuses more than MaxLabels labels and the labels with the
+// characters used to join them on the queue name and on the trigger.
+func ValidateLabels(labels []string) error {
+	labels = NormalizeLabels(labels)
+	for _, label := range labels {
+		if strings.ContainsAny(label, "+,") {
+			return LabelsError{fmt.Sprintf("The label %s can't have the characters + and ,", label)}
+		}
+	}
+
+	if len(labels) > MaxLabels {
+		return LabelsError{fmt.Sprintf("At most %d labels are accepted, got %d", MaxLabels, len(labels))}
+	}
+
+	return nil
+}
+
+func QueueName(labels []string) string {
+	return queuePrefix + strings.Join(NormalizeLabels(labels), "+")
+}
+
+// ListenQueues returns a queue for every combination of the worker labels, so a
+// worker picks executions requiring any subset of the labels it advertises.
+func ListenQueues(labels []string) []string {
+	labels = NormalizeLabels(labels)
+	queues := []string{}
+	for mask := 1; mask < 1<<len(labels); mask++ {
+		combination := []string{}
+		for index, label := range labels {
+			if mask&(1<<index) != 0 {
+				combination = append(combination, label)
+			}
+		}
+
+		queues = append(queues, QueueName(combination))
+	}
+
+	return queues
+}
@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		labels []string
+		valid  bool
+	}{
+		{[]string{"docker", "GPU", " gpu "}, true},
+		{[]string{"a", "b", "c", "d", "e", "f", "g", "h", "h"}, true},
+		{[]string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}, false},
+		{[]string{"a+b"}, false},
+		{[]string{"a,b"}, false},
+	}
+
+	for _, test := range tests {
+		err := ValidateLabels(test.labels)
+		if (err == nil) != test.valid {
+			t.Errorf("ValidateLabels(%v) = %v, expected valid %v", test.labels, err, test.valid)
+		}
+
+		var labelsErr LabelsError
+		if err != nil && !errors.As(err, &labelsErr) {
+			t.Errorf("expected LabelsError, got %T", err)
+		}
+	}
+}
+
+func TestQueueNameIsUniqueForValidLabels(t *testing.T) {
+	if QueueName([]string{"b", "A"}) != "runner:a+b" {
+		t.Errorf("unexpected queue name %s", QueueName([]string{"b", "A"}))
+	}
+
+	if len(ListenQueues([]string{"a", "b"})) != 3 {
+		t.Errorf("expected a queue for every combination, got %v", ListenQueues([]string{"a", "b"}))
+	}
+}
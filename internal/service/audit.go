@@ -0,0 +1,147 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+	"go.uber.org/zap"
+)
+
+const maxAuditPageLimit = 200
+
+// sensitiveAuditFields are masked on the changes, even if an entity starts to
+// return them on JSON.
+var sensitiveAuditFields = map[string]bool{
+	"apikey":                true,
+	"apikeyhash":            true,
+	"deploykey":             true,
+	"envs":                  true,
+	"previoussigningsecret": true,
+	"repositorytoken":       true,
+	"secret":                true,
+	"signingsecret":         true,
+	"token":                 true,
+	"tokenhash":             true,
+}
+
+type AuditService struct {
+	repository repository.IAuditRepository
+	logger     *zap.Logger
+}
+
+func NewAuditService(
+	repository repository.IAuditRepository,
+	logger *zap.Logger,
+) *AuditService {
+	return &AuditService{
+		repository: repository,
+		logger:     logger,
+	}
+}
+
+// Record appends an audit event with the fields changed from before to after,
+// before is nil on creation and after is nil on deletion.
+func (a *AuditService) Record(
+	actor types.Actor, action string, triggerId uint, resource string, before interface{}, after interface{},
+) {
+	changes, err := json.Marshal(auditChanges(before, after))
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("Failed to write the changes of audit event %s: %v", action, err))
+		changes = []byte("{}")
+	}
+
+	a.repository.Save(&entities.AuditEvent{
+		Action:    action,
+		Actor:     actor.Name,
+		Ip:        actor.Ip,
+		TriggerId: triggerId,
+		Resource:  resource,
+		Changes:   changes,
+	})
+}
+
+func (a *AuditService) GetEvents(filter types.AuditFilter, page int, limit int) types.AuditPage {
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 || limit > maxAuditPageLimit {
+		limit = 50
+	}
+
+	events, total := a.repository.Find(filter, (page-1)*limit, limit)
+	return types.AuditPage{
+		Events: events,
+		Total:  total,
+		Page:   page,
+		Limit:  limit,
+	}
+}
+
+// Export writes the events as JSON Lines, one event per line.
+func (a *AuditService) Export(filter types.AuditFilter, write func(line []byte) error) error {
+	return a.repository.FindInBatches(filter, func(events []entities.AuditEvent) error {
+		for _, event := range events {
+			line, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+
+			if err := write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func auditFields(value interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return fields
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+
+	json.Unmarshal(data, &fields)
+	for key := range fields {
+		if sensitiveAuditFields[strings.ToLower(key)] {
+			fields[key] = "***"
+		}
+	}
+
+	return fields
+}
+
+func auditChanges(before interface{}, after interface{}) map[string]types.AuditChange {
+	beforeFields := auditFields(before)
+	afterFields := auditFields(after)
+	changes := map[string]types.AuditChange{}
+	for key, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[key]) {
+			changes[key] = types.AuditChange{Before: value, After: afterFields[key]}
+		}
+	}
+
+	for key, value := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			changes[key] = types.AuditChange{Before: nil, After: value}
+		}
+	}
+
+	// The fields of gorm.Model change on every update.
+	delete(changes, "UpdatedAt")
+	delete(changes, "CreatedAt")
+	delete(changes, "DeletedAt")
+
+	return changes
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
+	"github.com/tiago123456789/own-githubaction/pkg/oidc"
+	"go.uber.org/zap"
+)
+
+const sessionPrefix = "oss_"
+
+// loginStateDuration is how long the user has to login on the provider.
+const loginStateDuration = 10 * time.Minute
+
+// roleOrder chooses the role when the groups of the user map to many roles.
+var roleOrder = []string{RoleAdmin, RoleMaintainer, RoleTriggerOwner, RoleViewer}
+
+var ErrOidcDisabled = errors.New("OIDC login isn't configured")
+
+type loginState struct {
+	State     string    `json:"state"`
+	Verifier  string    `json:"verifier"`
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type AuthService struct {
+	users             *UserService
+	userRepository    repository.IUserRepository
+	sessionRepository repository.ISessionRepository
+	projectRepository repository.IProjectRepository
+	provider          oidc.IProvider
+	encryption        encryption.IEncryption
+	logger            *zap.Logger
+	auditService      *AuditService
+	roleMapping       map[string]string
+	projectMapping    map[string][]string
+	defaultRole       string
+	sessionDuration   time.Duration
+}
+
+func NewAuthService(
+	users *UserService,
+	userRepository repository.IUserRepository,
+	sessionRepository repository.ISessionRepository,
+	projectRepository repository.IProjectRepository,
+	provider oidc.IProvider,
+	encryption encryption.IEncryption,
+	logger *zap.Logger,
+	auditService *AuditService,
+) *AuthService {
+	roleMapping := map[string]string{}
+	for _, item := range strings.Split(os.Getenv("OIDC_ROLE_MAPPING"), ",") {
+		if len(strings.TrimSpace(item)) == 0 {
+			continue
+		}
+
+		separator := strings.LastIndex(item, ":")
+		if separator <= 0 {
+			log.Fatal("OIDC_ROLE_MAPPING must be like group1:role1,group2:role2")
+		}
+
+		group, role := strings.TrimSpace(item[:separator]), strings.TrimSpace(item[separator+1:])
+		if err := ValidateRole(role); err != nil {
+			log.Fatalf("OIDC_ROLE_MAPPING: %v", err)
+		}
+		roleMapping[group] = role
+	}
+
+	projectMapping := map[string][]string{}
+	for _, item := range strings.Split(os.Getenv("OIDC_PROJECT_MAPPING"), ",") {
+		if len(strings.TrimSpace(item)) == 0 {
+			continue
+		}
+
+		separator := strings.LastIndex(item, ":")
+		if separator <= 0 || separator == len(item)-1 {
+			log.Fatal("OIDC_PROJECT_MAPPING must be like group1:project1,group2:project2")
+		}
+
+		group, project := strings.TrimSpace(item[:separator]), strings.TrimSpace(item[separator+1:])
+		projectMapping[project] = append(projectMapping[project], group)
+	}
+
+	defaultRole := os.Getenv("OIDC_DEFAULT_ROLE")
+	if len(defaultRole) > 0 {
+		if err := ValidateRole(defaultRole); err != nil {
+			log.Fatalf("OIDC_DEFAULT_ROLE: %v", err)
+		}
+	}
+
+	sessionHours := 8
+	if value := os.Getenv("OIDC_SESSION_HOURS"); len(value) > 0 {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours <= 0 {
+			log.Fatal("OIDC_SESSION_HOURS must be a positive number")
+		}
+		sessionHours = hours
+	}
+
+	return &AuthService{
+		users:             users,
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		projectRepository: projectRepository,
+		provider:          provider,
+		encryption:        encryption,
+		logger:            logger,
+		auditService:      auditService,
+		roleMapping:       roleMapping,
+		projectMapping:    projectMapping,
+		defaultRole:       defaultRole,
+		sessionDuration:   time.Duration(sessionHours) * time.Hour,
+	}
+}
+
+// Authenticate returns the user of the session token, or of the api key and
+// api token.
+func (a *AuthService) Authenticate(apiKey string, ip string) (entities.User, error) {
+	if !strings.HasPrefix(apiKey, sessionPrefix) {
+		return a.users.Authenticate(apiKey, ip)
+	}
+
+	session := a.sessionRepository.FindByHash(hashApiKey(apiKey))
+	if session.ID == 0 || time.Now().After(session.ExpiresAt) {
+		return entities.User{}, ErrUnauthenticated
+	}
+
+	user := a.userRepository.FindById(session.UserId)
+	if user.ID == 0 || user.Disabled {
+		return entities.User{}, ErrUnauthenticated
+	}
+
+	return user, nil
+}
+
+// StartLogin returns the url of the provider to login and the state of the
+// login encrypted, kept on a cookie until the provider redirects back.
+func (a *AuthService) StartLogin() (string, string, error) {
+	if !a.provider.Enabled() {
+		return "", "", ErrOidcDisabled
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, challenge, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authUrl, err := a.provider.AuthCodeURL(state, challenge, nonce)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, _ := json.Marshal(loginState{
+		State:     state,
+		Verifier:  verifier,
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(loginStateDuration),
+	})
+	encryptedState, err := a.encryption.Encrypt(string(data))
+	if err != nil {
+		return "", "", err
+	}
+
+	return authUrl, encryptedState, nil
+}
+
+// FinishLogin exchanges the code for the ID token, creates or updates the user
+// with the role of the groups and returns the session token.
+func (a *AuthService) FinishLogin(
+	code string, state string, encryptedState string, ip string,
+) (entities.User, string, time.Time, error) {
+	user, token, expiresAt, err := a.finishLogin(code, state, encryptedState)
+	if err != nil {
+		// The actor is who the ID token claims to be when it was validated,
+		// otherwise nobody can be identified.
+		actor := types.Actor{Name: "anonymous", Ip: ip}
+		changes := map[string]string{"reason": err.Error()}
+		if len(user.OidcSubject) > 0 {
+			actor.Name = user.Name
+			changes["subject"] = user.OidcSubject
+		}
+
+		a.auditService.Record(actor, "auth.login_failed", 0, "session", nil, changes)
+		return entities.User{}, token, expiresAt, err
+	}
+
+	a.auditService.Record(
+		types.Actor{Name: user.Name, Ip: ip}, "auth.login", 0, fmt.Sprintf("user:%d", user.ID),
+		nil, map[string]interface{}{"role": user.Role, "expiresAt": expiresAt},
+	)
+	return user, token, expiresAt, nil
+}
+
+func (a *AuthService) finishLogin(
+	code string, state string, encryptedState string,
+) (entities.User, string, time.Time, error) {
+	if !a.provider.Enabled() {
+		return entities.User{}, "", time.Time{}, ErrOidcDisabled
+	}
+
+	data, err := a.encryption.Decrypt(encryptedState)
+	if err != nil {
+		return entities.User{}, "", time.Time{}, errors.New("The login state is invalid")
+	}
+
+	login := loginState{}
+	if err := json.Unmarshal([]byte(data), &login); err != nil ||
+		len(state) == 0 || login.State != state || time.Now().After(login.ExpiresAt) {
+		return entities.User{}, "", time.Time{}, errors.New("The login state is invalid")
+	}
+
+	claims, err := a.provider.Exchange(code, login.Verifier, login.Nonce)
+	if err != nil {
+		a.logger.Warn(fmt.Sprintf("Failed OIDC login: %v", err))
+		return entities.User{}, "", time.Time{}, errors.New("The login on the OIDC provider failed")
+	}
+
+	claimed := entities.User{Name: claims.Email, OidcSubject: claims.Subject}
+	if len(claimed.Name) == 0 {
+		claimed.Name = claims.Subject
+	}
+
+	role := a.roleOf(claims.Groups)
+	if len(role) == 0 {
+		a.disableUser(claims)
+		return claimed, "", time.Time{}, ErrForbidden
+	}
+
+	user, err := a.syncUser(claims, role)
+	if err != nil {
+		return claimed, "", time.Time{}, err
+	}
+
+	a.syncProjects(user, claims.Groups)
+
+	secret, err := generateApiKey()
+	if err != nil {
+		return claimed, "", time.Time{}, err
+	}
+
+	token := sessionPrefix + secret
+	session := entities.Session{
+		UserId:    user.ID,
+		TokenHash: hashApiKey(token),
+		ExpiresAt: time.Now().Add(a.sessionDuration),
+	}
+	a.sessionRepository.DeleteExpired(time.Now())
+	a.sessionRepository.Save(&session)
+
+	return user, token, session.ExpiresAt, nil
+}
+
+func (a *AuthService) Logout(token string, actor types.Actor) {
+	session := a.sessionRepository.FindByHash(hashApiKey(token))
+	if session.ID != 0 {
+		a.sessionRepository.Delete(&session)
+		a.auditService.Record(actor, "auth.logout", 0, fmt.Sprintf("user:%d", session.UserId), nil, nil)
+	}
+}
+
+func (a *AuthService) roleOf(groups []string) string {
+	roles := map[string]bool{}
+	for _, group := range groups {
+		if role, ok := a.roleMapping[group]; ok {
+			roles[role] = true
+		}
+	}
+
+	for _, role := range roleOrder {
+		if roles[role] {
+			return role
+		}
+	}
+
+	return a.defaultRole
+}
+
+// disableUser disables the user whose groups don't have a role anymore, and
+// ends the sessions and revokes the api tokens, so the access doesn't outlive
+// the groups on the provider.
+func (a *AuthService) disableUser(claims oidc.Claims) {
+	user := a.userRepository.FindByOidcSubject(claims.Subject)
+	if user.ID == 0 || user.Disabled {
+		return
+	}
+
+	a.userRepository.UpdateDisabled(&user, true)
+	a.userRepository.DeleteCredentials(&user)
+	a.auditService.Record(
+		types.Actor{Name: user.Name}, "user.disabled", 0, fmt.Sprintf("user:%d", user.ID),
+		map[string]bool{"disabled": false}, map[string]bool{"disabled": true},
+	)
+}
+
+// syncUser creates the user on the first login, and updates the role on every
+// login, so removing the user from a group on the provider removes the access.
+func (a *AuthService) syncUser(claims oidc.Claims, role string) (entities.User, error) {
+	user := a.userRepository.FindByOidcSubject(claims.Subject)
+	if user.ID != 0 {
+		if user.Role != role {
+			a.userRepository.UpdateRole(&user, role)
+			user.Role = role
+		}
+
+		if user.Disabled {
+			a.userRepository.UpdateDisabled(&user, false)
+			user.Disabled = false
+		}
+
+		return user, nil
+	}
+
+	name := claims.Email
+	if len(name) == 0 {
+		name = claims.Subject
+	}
+
+	if !userNamePattern.MatchString(name) {
+		return entities.User{}, fmt.Errorf("The name %s must have only letters, numbers and the characters _ . @ + -", name)
+	}
+
+	if a.userRepository.FindByName(name).ID != 0 {
+		return entities.User{}, fmt.Errorf("The user %s already exists and wasn't created by the OIDC login", name)
+	}
+
+	user = entities.User{
+		Name:        name,
+		Role:        role,
+		OidcSubject: claims.Subject,
+	}
+	a.userRepository.Save(&user)
+
+	return user, nil
+}
+
+// syncProjects adds the user to the projects mapped to the groups of the user,
+// and removes the user from the mapped projects of the groups the user left.
+// The projects not on OIDC_PROJECT_MAPPING are managed on the api.
+func (a *AuthService) syncProjects(user entities.User, groups []string) {
+	for name, projectGroups := range a.projectMapping {
+		project := a.projectRepository.FindByName(name)
+		if project.ID == 0 {
+			a.logger.Warn(fmt.Sprintf("The project %s of OIDC_PROJECT_MAPPING doesn't exist", name))
+			continue
+		}
+
+		isMember := false
+		for _, group := range projectGroups {
+			isMember = isMember || contains(groups, group)
+		}
+
+		member := a.projectRepository.FindMember(project.ID, user.ID)
+		if isMember && member.ID == 0 {
+			a.projectRepository.SaveMember(&entities.ProjectMember{ProjectId: project.ID, UserId: user.ID})
+		}
+
+		if !isMember && member.ID != 0 {
+			a.projectRepository.DeleteMember(&member)
+		}
+	}
+}
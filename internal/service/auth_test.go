@@ -0,0 +1,182 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
+	"github.com/tiago123456789/own-githubaction/pkg/oidc"
+	"github.com/tiago123456789/own-githubaction/pkg/oidc/oidctest"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestAuthService(t *testing.T, db *gorm.DB) (*AuthService, *oidctest.Issuer) {
+	issuer := oidctest.NewIssuer("own-githubaction")
+	t.Cleanup(issuer.Close)
+	issuer.Claims["sub"] = "user-1"
+	issuer.Claims["email"] = "dev@example.com"
+	issuer.Claims["groups"] = []string{"developers"}
+
+	t.Setenv("OIDC_ISSUER", issuer.URL)
+	t.Setenv("OIDC_CLIENT_ID", issuer.ClientId)
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:3000/auth/oidc/callback")
+	t.Setenv("OIDC_ROLE_MAPPING", "developers:maintainer")
+	t.Setenv("OIDC_DEFAULT_ROLE", "")
+	t.Setenv("OIDC_PROJECT_MAPPING", "")
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	encryptionService, err := encryption.NewWithKeys([]encryption.Key{{ID: "test", Value: key}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := NewAuthService(
+		newTestUserService(db),
+		repository.NewUserRepository(db),
+		repository.NewSessionRepository(db),
+		repository.NewProjectRepository(db),
+		oidc.New(),
+		encryptionService,
+		zap.NewNop(),
+		NewAuditService(repository.NewAuditRepository(db), zap.NewNop()),
+	)
+	return auth, issuer
+}
+
+func loginOnIssuer(t *testing.T, auth *AuthService, issuer *oidctest.Issuer) (string, error) {
+	authUrl, encryptedState, err := auth.StartLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state, err := issuer.Authorize(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, token, _, err := auth.FinishLogin(code, state, encryptedState, "127.0.0.1")
+	return token, err
+}
+
+func TestFinishLogin(t *testing.T) {
+	auth, issuer := newTestAuthService(t, newTestDB(t))
+
+	token, err := loginOnIssuer(t, auth, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := auth.Authenticate(token, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Name != "dev@example.com" || user.Role != RoleMaintainer || user.OidcSubject != "user-1" {
+		t.Errorf("unexpected user %+v", user)
+	}
+}
+
+func TestFinishLoginRefusesOtherState(t *testing.T) {
+	auth, issuer := newTestAuthService(t, newTestDB(t))
+
+	authUrl, encryptedState, err := auth.StartLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, err := issuer.Authorize(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := auth.FinishLogin(code, "other-state", encryptedState, "127.0.0.1"); err == nil {
+		t.Error("expected the login with other state is refused")
+	}
+}
+
+func TestFinishLoginRefusesInvalidIdToken(t *testing.T) {
+	auth, issuer := newTestAuthService(t, newTestDB(t))
+	issuer.Mutate = func(claims map[string]interface{}) { claims["aud"] = "other-client" }
+
+	if _, err := loginOnIssuer(t, auth, issuer); err == nil {
+		t.Error("expected the id_token of other client is refused")
+	}
+}
+
+func TestFinishLoginDisablesUserWithoutRole(t *testing.T) {
+	db := newTestDB(t)
+	auth, issuer := newTestAuthService(t, db)
+
+	token, err := loginOnIssuer(t, auth, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := auth.Authenticate(token, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, err := auth.users.CreateApiToken(user, types.ApiToken{
+		Name: "deploy", Scopes: []string{PermissionExecutionsRun}, ExpiresInDays: 1,
+	}, types.Actor{Name: user.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.Claims["groups"] = []string{"marketing"}
+	if _, err := loginOnIssuer(t, auth, issuer); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected the login without role is forbidden, got %v", err)
+	}
+
+	if _, err := auth.Authenticate(token, "127.0.0.1"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected the session is ended, got %v", err)
+	}
+
+	if _, err := auth.Authenticate(newToken.Token, "127.0.0.1"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected the api token is revoked, got %v", err)
+	}
+
+	if disabled := repository.NewUserRepository(db).FindById(user.ID); !disabled.Disabled {
+		t.Error("expected the user is disabled")
+	}
+
+	issuer.Claims["groups"] = []string{"developers"}
+	token, err = loginOnIssuer(t, auth, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := auth.Authenticate(token, "127.0.0.1"); err != nil {
+		t.Errorf("expected the user is enabled again on the login with role, got %v", err)
+	}
+}
+
+func TestFinishLoginFailureActor(t *testing.T) {
+	db := newTestDB(t)
+	auth, issuer := newTestAuthService(t, db)
+	auditService := NewAuditService(repository.NewAuditRepository(db), zap.NewNop())
+
+	if _, _, _, err := auth.FinishLogin("code", "state", "invalid", "127.0.0.1"); err == nil {
+		t.Fatal("expected the invalid state refused")
+	}
+
+	if events := auditService.GetEvents(types.AuditFilter{Actor: "anonymous"}, 1, 10); events.Total != 1 {
+		t.Errorf("expected the login without valid ID token recorded as anonymous, got %d events", events.Total)
+	}
+
+	issuer.Claims["groups"] = []string{"marketing"}
+	if _, err := loginOnIssuer(t, auth, issuer); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	events := auditService.GetEvents(types.AuditFilter{Action: "auth.login_failed", Actor: "dev@example.com"}, 1, 10)
+	if events.Total != 1 {
+		t.Errorf("expected the login with valid ID token recorded with the claimed email, got %d events", events.Total)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
+	"go.uber.org/zap"
+)
+
+type EncryptionService struct {
+	triggerRepository repository.ITriggerRepository
+	encryption        encryption.IEncryption
+	logger            *zap.Logger
+}
+
+func NewEncryptionService(
+	triggerRepository repository.ITriggerRepository,
+	encryption encryption.IEncryption,
+	logger *zap.Logger,
+) *EncryptionService {
+	return &EncryptionService{
+		triggerRepository: triggerRepository,
+		encryption:        encryption,
+		logger:            logger,
+	}
+}
+
+// Reencrypt encrypts again the sensitive fields not encrypted by the active
+// key, returning how many registers changed.
+func (e *EncryptionService) Reencrypt() (int, error) {
+	total := 0
+	for _, trigger := range e.triggerRepository.FindAll() {
+		fields := []*string{
+			&trigger.SigningSecret,
+			&trigger.PreviousSigningSecret,
+		}
+
+		changed := false
+		for _, field := range fields {
+			if e.encryption.IsCurrent(*field) {
+				continue
+			}
+
+			value, err := e.encryption.Rewrap(*field)
+			if err != nil {
+				e.logger.Error(
+					fmt.Sprintf("Failed to encrypt again the trigger %d: %v", trigger.ID, err),
+				)
+				return total, err
+			}
+
+			*field = value
+			changed = true
+		}
+
+		if !changed {
+			continue
+		}
+
+		e.triggerRepository.UpdateTriggerData(&trigger, entities.Trigger{
+			SigningSecret:         trigger.SigningSecret,
+			PreviousSigningSecret: trigger.PreviousSigningSecret,
+		})
+		total++
+	}
+
+	return total, nil
+}
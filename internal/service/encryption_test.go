@@ -0,0 +1,78 @@
+package service
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
+	"go.uber.org/zap"
+)
+
+func TestReencrypt(t *testing.T) {
+	db := newTestDB(t)
+	triggers := repository.NewTriggerRepository(db)
+
+	oldKey := encryption.Key{ID: "key1", Value: make([]byte, 32)}
+	currentKey := encryption.Key{ID: "key2", Value: make([]byte, 32)}
+	rand.Read(oldKey.Value)
+	rand.Read(currentKey.Value)
+	before, err := encryption.NewWithKeys([]encryption.Key{oldKey}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := encryption.NewWithKeys([]encryption.Key{oldKey, currentKey}, "key2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingSecret, _ := before.Encrypt("signing-secret")
+	previousSigningSecret, _ := before.Encrypt("previous-signing-secret")
+	currentSecret, _ := rotated.Encrypt("current-secret")
+	stored := []entities.Trigger{
+		{Hash: "old", SigningSecret: signingSecret, PreviousSigningSecret: previousSigningSecret},
+		{Hash: "legacy", SigningSecret: "plaintext-secret"},
+		{Hash: "current", SigningSecret: currentSecret},
+		{Hash: "without-secret"},
+	}
+	for i := range stored {
+		triggers.Save(&stored[i])
+	}
+
+	total, err := NewEncryptionService(triggers, rotated, zap.NewNop()).Reencrypt()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 2 {
+		t.Errorf("expected 2 triggers encrypted again, got %d", total)
+	}
+
+	onlyCurrentKey, err := encryption.NewWithKeys([]encryption.Key{currentKey}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][2]string{
+		"old":     {"signing-secret", "previous-signing-secret"},
+		"legacy":  {"plaintext-secret", ""},
+		"current": {"current-secret", ""},
+	}
+	for _, trigger := range triggers.FindAll() {
+		for i, field := range []string{trigger.SigningSecret, trigger.PreviousSigningSecret} {
+			if !rotated.IsCurrent(field) {
+				t.Errorf("expected the fields of trigger %s encrypted by key2, got %s", trigger.Hash, field)
+			}
+
+			value, err := onlyCurrentKey.Decrypt(field)
+			if err != nil || value != expected[trigger.Hash][i] {
+				t.Errorf("expected %q on trigger %s, got %q %v", expected[trigger.Hash][i], trigger.Hash, value, err)
+			}
+		}
+	}
+
+	if total, err := NewEncryptionService(triggers, rotated, zap.NewNop()).Reencrypt(); err != nil || total != 0 {
+		t.Errorf("expected nothing to encrypt on the next run, got %d %v", total, err)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type ProjectService struct {
+	repository     repository.IProjectRepository
+	userRepository repository.IUserRepository
+	auditService   *AuditService
+}
+
+func NewProjectService(
+	repository repository.IProjectRepository,
+	userRepository repository.IUserRepository,
+	auditService *AuditService,
+) *ProjectService {
+	return &ProjectService{
+		repository:     repository,
+		userRepository: userRepository,
+		auditService:   auditService,
+	}
+}
+
+// GetProjects returns the projects the user is member of, or every project
+// for the admins.
+func (p *ProjectService) GetProjects(user entities.User) []entities.Project {
+	if user.Role == RoleAdmin {
+		return p.repository.FindAll()
+	}
+
+	return p.repository.FindByUserId(user.ID)
+}
+
+func (p *ProjectService) CreateProject(data types.Project, actor types.Actor) (entities.Project, error) {
+	if !projectNamePattern.MatchString(data.Name) {
+		return entities.Project{}, fmt.Errorf("The project name %s must have only letters, numbers, underscore and dash", data.Name)
+	}
+
+	if p.repository.FindByName(data.Name).ID != 0 {
+		return entities.Project{}, fmt.Errorf("The project %s already exists", data.Name)
+	}
+
+	project := entities.Project{
+		Name:         data.Name,
+		SecretPrefix: fmt.Sprintf("project-%s", uuid.NewString()),
+	}
+	p.repository.Save(&project)
+	p.auditService.Record(actor, "project.created", 0, fmt.Sprintf("project:%d", project.ID), nil, project)
+
+	return project, nil
+}
+
+func (p *ProjectService) GetMembers(projectId uint) ([]entities.ProjectMember, error) {
+	if p.repository.FindById(projectId).ID == 0 {
+		return nil, ErrNotFound
+	}
+
+	return p.repository.FindMembers(projectId), nil
+}
+
+func (p *ProjectService) AddMember(projectId uint, userId uint, actor types.Actor) (entities.ProjectMember, error) {
+	if p.repository.FindById(projectId).ID == 0 || p.userRepository.FindById(userId).ID == 0 {
+		return entities.ProjectMember{}, ErrNotFound
+	}
+
+	member := p.repository.FindMember(projectId, userId)
+	if member.ID != 0 {
+		return member, nil
+	}
+
+	member = entities.ProjectMember{ProjectId: projectId, UserId: userId}
+	p.repository.SaveMember(&member)
+	p.auditService.Record(
+		actor, "project_member.added", 0, fmt.Sprintf("project:%d/member:%d", projectId, userId), nil, member,
+	)
+
+	return member, nil
+}
+
+func (p *ProjectService) DeleteMember(projectId uint, userId uint, actor types.Actor) error {
+	member := p.repository.FindMember(projectId, userId)
+	if member.ID == 0 {
+		return ErrNotFound
+	}
+
+	p.repository.DeleteMember(&member)
+	p.auditService.Record(
+		actor, "project_member.deleted", 0, fmt.Sprintf("project:%d/member:%d", projectId, userId), member, nil,
+	)
+	return nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/runner"
+	"go.uber.org/zap"
+)
+
+type RunnerService struct {
+	repository repository.IRunnerRepository
+	logger     *zap.Logger
+}
+
+func NewRunnerService(
+	repository repository.IRunnerRepository,
+	logger *zap.Logger,
+) *RunnerService {
+	return &RunnerService{
+		repository: repository,
+		logger:     logger,
+	}
+}
+
+func (r *RunnerService) GetRunners() []entities.Runner {
+	return r.repository.FindAll()
+}
+
+func (r *RunnerService) ReportCapacity(
+	name string, labels []string, admission runner.IAdmission,
+) {
+	capacity, err := admission.Capacity()
+	if err != nil {
+		r.logger.Warn(
+			fmt.Sprintf("Failed to read the capacity of runner %s: %v", name, err),
+		)
+	}
+
+	r.repository.SaveByName(&entities.Runner{
+		Name:         name,
+		Labels:       strings.Join(labels, ","),
+		Cpus:         capacity.Cpus,
+		Load:         capacity.Load,
+		FreeMemoryMb: capacity.FreeMemoryMb,
+		FreeDiskMb:   capacity.FreeDiskMb,
+		Running:      admission.Running(),
+		LastSeenAt:   time.Now(),
+	})
+}
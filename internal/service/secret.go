@@ -0,0 +1,713 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+	secretmanager "github.com/tiago123456789/own-githubaction/pkg/secret_manager"
+	"go.uber.org/zap"
+)
+
+var ErrNotFound = errors.New("Not found register")
+
+var secretKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var secretGroupNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+func ValidateSecretKey(key string) error {
+	if !secretKeyPattern.MatchString(key) {
+		return fmt.Errorf("The key %s must have only letters, numbers and underscore and can't start with number", key)
+	}
+
+	return nil
+}
+
+func ValidateSecretGroupName(name string) error {
+	if !secretGroupNamePattern.MatchString(name) {
+		return fmt.Errorf("The secret group name %s must have only letters, numbers, underscore and dash", name)
+	}
+
+	return nil
+}
+
+// branchPattern compiles a branch pattern like the branch filters of Github
+// Actions: * and ? don't match /, ** matches any characters including /.
+func branchPattern(pattern string) (*regexp.Regexp, error) {
+	expression := strings.Builder{}
+	expression.WriteString("^")
+	for index := 0; index < len(pattern); index++ {
+		switch char := pattern[index]; char {
+		case '*':
+			if index+1 < len(pattern) && pattern[index+1] == '*' {
+				expression.WriteString(".*")
+				index++
+			} else {
+				expression.WriteString("[^/]*")
+			}
+		case '?':
+			expression.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[index+1:], ']')
+			if end < 0 {
+				return nil, errors.New("unterminated character class")
+			}
+
+			expression.WriteString(pattern[index : index+end+2])
+			index += end + 1
+		case '\\':
+			if index+1 == len(pattern) {
+				return nil, errors.New("trailing backslash")
+			}
+
+			index++
+			expression.WriteString(regexp.QuoteMeta(pattern[index : index+1]))
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	expression.WriteString("$")
+
+	return regexp.Compile(expression.String())
+}
+
+func ValidateSecretPolicy(policy types.SecretPolicy) error {
+	for _, branch := range policy.Branches {
+		if _, err := branchPattern(branch); err != nil || len(branch) == 0 || strings.Contains(branch, ",") {
+			return fmt.Errorf("The branch pattern %s is invalid", branch)
+		}
+	}
+
+	for _, value := range append(policy.Events, policy.Environments...) {
+		if len(value) == 0 || strings.Contains(value, ",") {
+			return errors.New("The fields events and environments can't have empty values or comma")
+		}
+	}
+
+	return nil
+}
+
+// SecretOwner is who the secrets belong to: a trigger, a group or a project.
+type SecretOwner struct {
+	TriggerId uint
+	GroupId   uint
+	ProjectId uint
+	prefix    string
+	trigger   *entities.Trigger
+}
+
+// SecretScope is where the execution runs, used to check the secret policies.
+type SecretScope struct {
+	Ref         string
+	Event       string
+	Environment string
+}
+
+func splitList(value string) []string {
+	if len(value) == 0 {
+		return []string{}
+	}
+
+	return strings.Split(value, ",")
+}
+
+// allows checks the policy of the secret. The branches accept the patterns of
+// branchPattern, and a branch without the refs/ prefix means refs/heads/<branch>.
+func (s SecretScope) allows(secret entities.Secret) bool {
+	if branches := splitList(secret.Branches); len(branches) > 0 {
+		matched := false
+		for _, branch := range branches {
+			if !strings.HasPrefix(branch, "refs/") {
+				branch = "refs/heads/" + branch
+			}
+
+			pattern, err := branchPattern(branch)
+			matched = matched || (err == nil && pattern.MatchString(s.Ref))
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	if events := splitList(secret.Events); len(events) > 0 && !contains(events, s.Event) {
+		return false
+	}
+
+	if environments := splitList(secret.Environments); len(environments) > 0 &&
+		!contains(environments, s.Environment) {
+		return false
+	}
+
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type SecretService struct {
+	repository        repository.ISecretRepository
+	groupRepository   repository.ISecretGroupRepository
+	triggerRepository repository.ITriggerRepository
+	projectRepository repository.IProjectRepository
+	secretManager     secretmanager.ISecretManager
+	logger            *zap.Logger
+	auditService      *AuditService
+}
+
+func NewSecretService(
+	repository repository.ISecretRepository,
+	groupRepository repository.ISecretGroupRepository,
+	triggerRepository repository.ITriggerRepository,
+	projectRepository repository.IProjectRepository,
+	secretManager secretmanager.ISecretManager,
+	logger *zap.Logger,
+	auditService *AuditService,
+) *SecretService {
+	return &SecretService{
+		repository:        repository,
+		groupRepository:   groupRepository,
+		triggerRepository: triggerRepository,
+		projectRepository: projectRepository,
+		secretManager:     secretManager,
+		logger:            logger,
+		auditService:      auditService,
+	}
+}
+
+// secretName is the key of one version of the secret on the secret manager.
+func secretName(owner SecretOwner, key string, version int) string {
+	return fmt.Sprintf("%s-%s-v%d", owner.prefix, key, version)
+}
+
+func triggerSecretOwner(trigger entities.Trigger) SecretOwner {
+	return SecretOwner{TriggerId: trigger.ID, prefix: trigger.Hash, trigger: &trigger}
+}
+
+func groupSecretOwner(group entities.SecretGroup) SecretOwner {
+	return SecretOwner{GroupId: group.ID, prefix: fmt.Sprintf("group-%s", group.Hash)}
+}
+
+func projectSecretOwner(project entities.Project) SecretOwner {
+	return SecretOwner{ProjectId: project.ID, prefix: project.SecretPrefix}
+}
+
+func (s *SecretService) TriggerOwner(projectId uint, triggerId uint) (SecretOwner, error) {
+	trigger := s.triggerRepository.FindById(triggerId)
+	if trigger.ID == 0 || trigger.ProjectId != projectId {
+		return SecretOwner{}, ErrNotFound
+	}
+
+	return triggerSecretOwner(trigger), nil
+}
+
+func (s *SecretService) GroupOwner(projectId uint, groupId uint) (SecretOwner, error) {
+	group := s.groupRepository.FindById(groupId)
+	if group.ID == 0 || group.ProjectId != projectId {
+		return SecretOwner{}, ErrNotFound
+	}
+
+	return groupSecretOwner(group), nil
+}
+
+func (s *SecretService) ProjectOwner(projectId uint) (SecretOwner, error) {
+	project := s.projectRepository.FindById(projectId)
+	if project.ID == 0 {
+		return SecretOwner{}, ErrNotFound
+	}
+
+	return projectSecretOwner(project), nil
+}
+
+func (o SecretOwner) resource(key string) string {
+	if o.TriggerId != 0 {
+		return fmt.Sprintf("trigger:%d/secret:%s", o.TriggerId, key)
+	}
+
+	if o.GroupId != 0 {
+		return fmt.Sprintf("secret-group:%d/secret:%s", o.GroupId, key)
+	}
+
+	return fmt.Sprintf("project:%d/secret:%s", o.ProjectId, key)
+}
+
+// audit saves the history of the secret, and the audit event with the
+// versions before and after, without the values.
+func (s *SecretService) audit(
+	owner SecretOwner, key string, version int, action string, actor types.Actor,
+	before interface{}, after interface{},
+) {
+	s.repository.SaveAudit(&entities.SecretAudit{
+		TriggerId: owner.TriggerId,
+		GroupId:   owner.GroupId,
+		ProjectId: owner.ProjectId,
+		Key:       key,
+		Version:   version,
+		Action:    action,
+		Actor:     actor.Name,
+		Ip:        actor.Ip,
+	})
+	s.auditService.Record(
+		actor, "secret."+strings.ReplaceAll(action, " ", "_"),
+		owner.TriggerId, owner.resource(key), before, after,
+	)
+}
+
+func (s *SecretService) setSecret(
+	owner SecretOwner, key string, value string, action string, actor types.Actor,
+) (entities.Secret, error) {
+	secret := entities.Secret{
+		TriggerId: owner.TriggerId,
+		GroupId:   owner.GroupId,
+		ProjectId: owner.ProjectId,
+		Key:       key,
+		UpdatedBy: actor.Name,
+	}
+	// The new version keeps the policy of the current version.
+	var before interface{}
+	if current, ok := s.findCurrent(owner, key); ok {
+		before = current
+		secret.Branches = current.Branches
+		secret.Events = current.Events
+		secret.Environments = current.Environments
+	}
+
+	version := s.repository.FindLastVersion(owner.TriggerId, owner.GroupId, owner.ProjectId, key) + 1
+	err := s.secretManager.Add(secretName(owner, key, version), value)
+	if err != nil {
+		s.logger.Error(
+			fmt.Sprintf("Failed to save version %d of secret %s of %s: %v", version, key, owner.prefix, err),
+		)
+		return entities.Secret{}, err
+	}
+
+	secret.Version = version
+	s.repository.Save(&secret)
+	s.audit(owner, key, version, action, actor, before, secret)
+
+	return secret, nil
+}
+
+func (s *SecretService) findCurrent(owner SecretOwner, key string) (entities.Secret, bool) {
+	for _, secret := range s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId) {
+		if secret.Key == key {
+			return secret, true
+		}
+	}
+
+	return entities.Secret{}, false
+}
+
+func (s *SecretService) deleteSecret(owner SecretOwner, key string, actor types.Actor) bool {
+	versions := s.repository.DeleteByKey(owner.TriggerId, owner.GroupId, owner.ProjectId, key)
+	for _, version := range versions {
+		err := s.secretManager.Delete(secretName(owner, key, version.Version))
+		if err != nil {
+			s.logger.Warn(
+				fmt.Sprintf("Failed to delete version %d of secret %s of %s: %v", version.Version, key, owner.prefix, err),
+			)
+		}
+	}
+
+	if len(versions) == 0 {
+		return false
+	}
+
+	lastVersion := versions[len(versions)-1]
+	s.audit(owner, key, lastVersion.Version, "deleted", actor, lastVersion, nil)
+	return true
+}
+
+// DeleteTriggerSecrets deletes every secret of the deleted trigger, including
+// the JSON of the triggers created before the versioning.
+func (s *SecretService) DeleteTriggerSecrets(trigger entities.Trigger, actor types.Actor) {
+	owner := triggerSecretOwner(trigger)
+	current := s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId)
+	if trigger.HasEnvs && len(current) == 0 {
+		if err := s.secretManager.Delete(trigger.Hash); err != nil {
+			s.logger.Warn(
+				fmt.Sprintf("Failed to delete legacy secrets of trigger %d: %v", trigger.ID, err),
+			)
+		}
+	}
+
+	for _, secret := range current {
+		s.deleteSecret(owner, secret.Key, actor)
+	}
+}
+
+func (s *SecretService) updateHasEnvs(owner SecretOwner) {
+	if owner.trigger == nil {
+		return
+	}
+
+	hasEnvs := len(s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId)) > 0
+	if owner.trigger.HasEnvs != hasEnvs {
+		s.triggerRepository.UpdateHasEnvs(owner.trigger, hasEnvs)
+	}
+}
+
+// importLegacySecrets moves the secrets of triggers created before the
+// versioning, saved as one JSON on the secret manager, to one secret per key.
+func (s *SecretService) importLegacySecrets(owner SecretOwner, actor types.Actor) error {
+	if owner.trigger == nil || !owner.trigger.HasEnvs ||
+		len(s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId)) > 0 {
+		return nil
+	}
+
+	secrets, err := s.getLegacySecrets(*owner.trigger)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range secrets {
+		if _, err := s.setSecret(owner, key, value, "imported", actor); err != nil {
+			return err
+		}
+	}
+
+	if err := s.secretManager.Delete(owner.trigger.Hash); err != nil {
+		s.logger.Warn(
+			fmt.Sprintf("Failed to delete legacy secrets of trigger %d: %v", owner.TriggerId, err),
+		)
+	}
+
+	return nil
+}
+
+func (s *SecretService) getLegacySecrets(trigger entities.Trigger) (map[string]string, error) {
+	secret, err := s.secretManager.Get(trigger.Hash)
+	if err != nil {
+		s.logger.Error(
+			fmt.Sprintf("Failed to get secret: %v", err),
+		)
+		return nil, err
+	}
+
+	secrets := map[string]string{}
+	json.Unmarshal([]byte(secret), &secrets)
+	return secrets, nil
+}
+
+func (s *SecretService) GetSecrets(owner SecretOwner, actor types.Actor) ([]entities.Secret, error) {
+	if err := s.importLegacySecrets(owner, actor); err != nil {
+		return nil, err
+	}
+
+	return s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId), nil
+}
+
+func (s *SecretService) SetSecret(
+	owner SecretOwner, key string, value string, actor types.Actor,
+) (entities.Secret, error) {
+	if err := s.importLegacySecrets(owner, actor); err != nil {
+		return entities.Secret{}, err
+	}
+
+	secret, err := s.setSecret(owner, key, value, "updated", actor)
+	if err != nil {
+		return entities.Secret{}, err
+	}
+
+	s.updateHasEnvs(owner)
+	return secret, nil
+}
+
+// SaveSecrets creates the first version of the secrets of a new trigger.
+func (s *SecretService) SaveSecrets(
+	trigger entities.Trigger, secrets map[string]string, actor types.Actor,
+) error {
+	owner := triggerSecretOwner(trigger)
+	for key, value := range secrets {
+		if _, err := s.setSecret(owner, key, value, "created", actor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SecretService) DeleteSecret(owner SecretOwner, key string, actor types.Actor) error {
+	if err := s.importLegacySecrets(owner, actor); err != nil {
+		return err
+	}
+
+	if !s.deleteSecret(owner, key, actor) {
+		return ErrNotFound
+	}
+
+	s.updateHasEnvs(owner)
+	return nil
+}
+
+// RotateSecrets replaces every secret of the owner by a new version. The keys
+// not sent are deleted.
+func (s *SecretService) RotateSecrets(
+	owner SecretOwner, secrets map[string]string, actor types.Actor,
+) ([]entities.Secret, error) {
+	if err := s.importLegacySecrets(owner, actor); err != nil {
+		return nil, err
+	}
+
+	for key, value := range secrets {
+		if _, err := s.setSecret(owner, key, value, "rotated", actor); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, secret := range s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId) {
+		if _, ok := secrets[secret.Key]; !ok {
+			s.deleteSecret(owner, secret.Key, actor)
+		}
+	}
+
+	s.updateHasEnvs(owner)
+	return s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId), nil
+}
+
+func (s *SecretService) SetSecretPolicy(
+	owner SecretOwner, key string, policy types.SecretPolicy, actor types.Actor,
+) (entities.Secret, error) {
+	if err := s.importLegacySecrets(owner, actor); err != nil {
+		return entities.Secret{}, err
+	}
+
+	secret, ok := s.findCurrent(owner, key)
+	if !ok {
+		return entities.Secret{}, ErrNotFound
+	}
+
+	s.repository.UpdatePolicy(&secret, entities.Secret{
+		Branches:     strings.Join(policy.Branches, ","),
+		Events:       strings.Join(policy.Events, ","),
+		Environments: strings.Join(policy.Environments, ","),
+	})
+	before := secret
+	secret, _ = s.findCurrent(owner, key)
+	s.audit(owner, key, secret.Version, "policy updated", actor, before, secret)
+
+	return secret, nil
+}
+
+func (s *SecretService) GetSecretAudits(owner SecretOwner) []entities.SecretAudit {
+	return s.repository.FindAudits(owner.TriggerId, owner.GroupId, owner.ProjectId)
+}
+
+func (s *SecretService) GetGroups(projectId uint) []entities.SecretGroup {
+	return s.groupRepository.FindAll(projectId)
+}
+
+// validateTriggers checks the triggers exist on the project of the group.
+func (s *SecretService) validateTriggers(projectId uint, triggers []uint) error {
+	for _, triggerId := range triggers {
+		if s.triggerRepository.FindById(triggerId).ProjectId != projectId {
+			return fmt.Errorf("The trigger %d doesn't exist", triggerId)
+		}
+	}
+
+	return nil
+}
+
+func joinTriggerIds(triggers []uint) string {
+	ids := []string{}
+	for _, triggerId := range triggers {
+		ids = append(ids, strconv.FormatUint(uint64(triggerId), 10))
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func (s *SecretService) CreateGroup(
+	projectId uint, group types.SecretGroup, actor types.Actor,
+) (entities.SecretGroup, error) {
+	if len(s.groupRepository.FindByNames(projectId, []string{group.Name})) > 0 {
+		return entities.SecretGroup{}, fmt.Errorf("The secret group %s already exists", group.Name)
+	}
+
+	if err := s.validateTriggers(projectId, group.AllowedTriggers); err != nil {
+		return entities.SecretGroup{}, err
+	}
+
+	groupToSave := entities.SecretGroup{
+		ProjectId:       projectId,
+		Name:            group.Name,
+		Hash:            uuid.NewString(),
+		AllowedTriggers: joinTriggerIds(group.AllowedTriggers),
+	}
+	s.groupRepository.Save(&groupToSave)
+	s.auditService.Record(
+		actor, "secret_group.created", 0, fmt.Sprintf("secret-group:%d", groupToSave.ID), nil, groupToSave,
+	)
+
+	return groupToSave, nil
+}
+
+func (s *SecretService) UpdateGroupTriggers(
+	projectId uint, groupId uint, triggers []uint, actor types.Actor,
+) (entities.SecretGroup, error) {
+	group := s.groupRepository.FindById(groupId)
+	if group.ID == 0 || group.ProjectId != projectId {
+		return entities.SecretGroup{}, ErrNotFound
+	}
+
+	if err := s.validateTriggers(projectId, triggers); err != nil {
+		return group, err
+	}
+
+	before := group
+	group.AllowedTriggers = joinTriggerIds(triggers)
+	s.groupRepository.UpdateAllowedTriggers(&group, group.AllowedTriggers)
+	s.auditService.Record(
+		actor, "secret_group.triggers_updated", 0, fmt.Sprintf("secret-group:%d", group.ID), before, group,
+	)
+
+	return group, nil
+}
+
+// ValidateGroups checks the secret groups referenced by a trigger exist on
+// the project of the trigger.
+func (s *SecretService) ValidateGroups(projectId uint, names []string) error {
+	groups := s.groupRepository.FindByNames(projectId, names)
+	for _, name := range names {
+		found := false
+		for _, group := range groups {
+			found = found || group.Name == name
+		}
+
+		if !found {
+			return fmt.Errorf("The secret group %s doesn't exist", name)
+		}
+	}
+
+	return nil
+}
+
+func (s *SecretService) SetTriggerGroups(triggerId uint, names []string, actor types.Actor) error {
+	trigger := s.triggerRepository.FindById(triggerId)
+	if trigger.ID == 0 {
+		return ErrNotFound
+	}
+
+	if err := s.ValidateGroups(trigger.ProjectId, names); err != nil {
+		return err
+	}
+
+	s.triggerRepository.UpdateSecretGroups(&trigger, strings.Join(names, ","))
+	s.auditService.Record(
+		actor, "trigger.secret_groups_updated", trigger.ID, fmt.Sprintf("trigger:%d", trigger.ID),
+		map[string]string{"secretGroups": trigger.SecretGroups},
+		map[string]string{"secretGroups": strings.Join(names, ",")},
+	)
+	return nil
+}
+
+func (s *SecretService) getOwnerValues(
+	owner SecretOwner, scope SecretScope, values map[string]string,
+) error {
+	for _, secret := range s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId) {
+		if !scope.allows(secret) {
+			s.logger.Info(
+				fmt.Sprintf(
+					"The secret %s of %s isn't allowed on ref %s event %s environment %s",
+					secret.Key, owner.prefix, scope.Ref, scope.Event, scope.Environment,
+				),
+			)
+			continue
+		}
+
+		value, err := s.secretManager.Get(secretName(owner, secret.Key, secret.Version))
+		if err != nil {
+			s.logger.Error(
+				fmt.Sprintf("Failed to get version %d of secret %s of %s: %v", secret.Version, secret.Key, owner.prefix, err),
+			)
+			return err
+		}
+
+		values[secret.Key] = value
+	}
+
+	return nil
+}
+
+func isTriggerAllowed(group entities.SecretGroup, triggerId uint) bool {
+	for _, id := range strings.Split(group.AllowedTriggers, ",") {
+		if id == strconv.FormatUint(uint64(triggerId), 10) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetValues returns the secrets to use on the execution of the trigger. The
+// secrets of the project of the trigger are overridden by the groups, in the
+// order referenced by the trigger, and the groups are overridden by the trigger
+// secrets. The secrets whose policy doesn't allow the scope of the execution
+// are skipped.
+func (s *SecretService) GetValues(trigger entities.Trigger, scope SecretScope) (map[string]string, error) {
+	values := map[string]string{}
+	if project := s.projectRepository.FindById(trigger.ProjectId); project.ID != 0 {
+		if err := s.getOwnerValues(projectSecretOwner(project), scope, values); err != nil {
+			return nil, err
+		}
+	}
+
+	names := []string{}
+	if len(trigger.SecretGroups) > 0 {
+		names = strings.Split(trigger.SecretGroups, ",")
+	}
+
+	groups := s.groupRepository.FindByNames(trigger.ProjectId, names)
+	for _, name := range names {
+		for _, group := range groups {
+			if group.Name != name {
+				continue
+			}
+
+			if !isTriggerAllowed(group, trigger.ID) {
+				s.logger.Warn(
+					fmt.Sprintf("The trigger %d isn't allowed to use the secret group %s", trigger.ID, name),
+				)
+				continue
+			}
+
+			if err := s.getOwnerValues(groupSecretOwner(group), scope, values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	owner := triggerSecretOwner(trigger)
+	if trigger.HasEnvs && len(s.repository.FindCurrent(owner.TriggerId, owner.GroupId, owner.ProjectId)) == 0 {
+		legacySecrets, err := s.getLegacySecrets(trigger)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range legacySecrets {
+			values[key] = value
+		}
+
+		return values, nil
+	}
+
+	if err := s.getOwnerValues(owner, scope, values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+func TestSecretScopeAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   entities.Secret
+		scope    SecretScope
+		expected bool
+	}{
+		{"without policy", entities.Secret{}, SecretScope{Ref: "refs/tags/v1"}, true},
+		{"branch", entities.Secret{Branches: "main"}, SecretScope{Ref: "refs/heads/main"}, true},
+		{"other branch", entities.Secret{Branches: "main"}, SecretScope{Ref: "refs/heads/develop"}, false},
+		{"one of the branches", entities.Secret{Branches: "main,release/*"}, SecretScope{Ref: "refs/heads/release/1.0"}, true},
+		{"glob", entities.Secret{Branches: "release-*"}, SecretScope{Ref: "refs/heads/release-1"}, true},
+		{"tag pattern", entities.Secret{Branches: "refs/tags/v*"}, SecretScope{Ref: "refs/tags/v1.2"}, true},
+		{"branch pattern on tag", entities.Secret{Branches: "v*"}, SecretScope{Ref: "refs/tags/v1.2"}, false},
+		{"star on nested branch", entities.Secret{Branches: "release/*"}, SecretScope{Ref: "refs/heads/release/1.0/hotfix"}, false},
+		{"double star", entities.Secret{Branches: "release/**"}, SecretScope{Ref: "refs/heads/release/1.0/hotfix"}, true},
+		{"double star in the middle", entities.Secret{Branches: "feature/**/fix"}, SecretScope{Ref: "refs/heads/feature/a/b/fix"}, true},
+		{"double star on every ref", entities.Secret{Branches: "refs/**"}, SecretScope{Ref: "refs/pull/1/merge"}, true},
+		{"question mark", entities.Secret{Branches: "v?"}, SecretScope{Ref: "refs/heads/v1"}, true},
+		{"question mark on slash", entities.Secret{Branches: "a?b"}, SecretScope{Ref: "refs/heads/a/b"}, false},
+		{"character class", entities.Secret{Branches: "v[0-9]"}, SecretScope{Ref: "refs/heads/v2"}, true},
+		{"dot is literal", entities.Secret{Branches: "v1.0"}, SecretScope{Ref: "refs/heads/v1x0"}, false},
+		{"escaped star", entities.Secret{Branches: "a\\*"}, SecretScope{Ref: "refs/heads/a*"}, true},
+		{"invalid pattern", entities.Secret{Branches: "release-["}, SecretScope{Ref: "refs/heads/release-["}, false},
+		{"event", entities.Secret{Events: "push,workflow_dispatch"}, SecretScope{Event: "push"}, true},
+		{"other event", entities.Secret{Events: "push"}, SecretScope{Event: "pull_request"}, false},
+		{"environment", entities.Secret{Environments: "production"}, SecretScope{Environment: "production"}, true},
+		{"without environment", entities.Secret{Environments: "production"}, SecretScope{}, false},
+		{
+			"every rule",
+			entities.Secret{Branches: "main", Events: "push", Environments: "production"},
+			SecretScope{Ref: "refs/heads/main", Event: "push", Environment: "staging"},
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		if allowed := test.scope.allows(test.secret); allowed != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, allowed)
+		}
+	}
+}
+
+func TestValidateSecretPolicy(t *testing.T) {
+	tests := []struct {
+		policy types.SecretPolicy
+		valid  bool
+	}{
+		{types.SecretPolicy{}, true},
+		{types.SecretPolicy{Branches: []string{"main", "release/**", "v[0-9]*"}}, true},
+		{types.SecretPolicy{Branches: []string{""}}, false},
+		{types.SecretPolicy{Branches: []string{"release-["}}, false},
+		{types.SecretPolicy{Branches: []string{"main\\"}}, false},
+		{types.SecretPolicy{Branches: []string{"main,develop"}}, false},
+		{types.SecretPolicy{Events: []string{"push"}, Environments: []string{"production"}}, true},
+		{types.SecretPolicy{Events: []string{""}}, false},
+		{types.SecretPolicy{Environments: []string{"a,b"}}, false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateSecretPolicy(test.policy); (err == nil) != test.valid {
+			t.Errorf("ValidateSecretPolicy(%+v) = %v, expected valid %v", test.policy, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(
+		sqlite.Open(filepath.Join(t.TempDir(), "database")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(
+		&entities.Trigger{}, &entities.Execution{},
+		&entities.ExecutionLog{}, &entities.Runner{},
+		&entities.Secret{}, &entities.SecretAudit{},
+		&entities.SecretGroup{}, &entities.Variable{},
+		&entities.VariableHistory{}, &entities.User{},
+		&entities.TriggerMember{}, &entities.ApiToken{},
+		&entities.Session{}, &entities.AuditEvent{},
+		&entities.Project{}, &entities.ProjectMember{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func newTestUserService(db *gorm.DB) *UserService {
+	return NewUserService(
+		repository.NewUserRepository(db),
+		repository.NewTriggerRepository(db),
+		repository.NewApiTokenRepository(db),
+		repository.NewProjectRepository(db),
+		NewAuditService(repository.NewAuditRepository(db), zap.NewNop()),
+	)
+}
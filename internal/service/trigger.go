@@ -1,22 +1,33 @@
 package service
 
 import (
-	"bufio"
-	"encoding/json"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/executor"
 	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/runner"
 	"github.com/tiago123456789/own-githubaction/internal/types"
+	"github.com/tiago123456789/own-githubaction/pkg/alert"
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
 	"github.com/tiago123456789/own-githubaction/pkg/file"
+	"github.com/tiago123456789/own-githubaction/pkg/github"
+	"github.com/tiago123456789/own-githubaction/pkg/logger"
 	"github.com/tiago123456789/own-githubaction/pkg/queue"
 	secretmanager "github.com/tiago123456789/own-githubaction/pkg/secret_manager"
+	"github.com/tiago123456789/own-githubaction/pkg/sshkey"
+	"github.com/tiago123456789/own-githubaction/pkg/workflow"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +38,14 @@ type TriggerService struct {
 	producer      queue.IProducer
 	queueUtil     queue.IQueueUtil
 	file          file.IFile
+	github        github.IGithub
+	admission     runner.IAdmission
+	executors     executor.Registry
+	encryption    encryption.IEncryption
+	secrets       *SecretService
+	variables     *VariableService
+	alert         alert.IAlert
+	auditService  *AuditService
 }
 
 func NewTriggerService(
@@ -35,7 +54,14 @@ func NewTriggerService(
 	repository repository.ITriggerRepository,
 	queueUtil queue.IQueueUtil,
 	file file.IFile,
-
+	github github.IGithub,
+	admission runner.IAdmission,
+	executors executor.Registry,
+	encryption encryption.IEncryption,
+	secrets *SecretService,
+	variables *VariableService,
+	alert alert.IAlert,
+	auditService *AuditService,
 ) *TriggerService {
 	return &TriggerService{
 		secretManager: secretManager,
@@ -44,11 +70,24 @@ func NewTriggerService(
 		repository:    repository,
 		queueUtil:     queueUtil,
 		file:          file,
+		github:        github,
+		admission:     admission,
+		executors:     executors,
+		encryption:    encryption,
+		secrets:       secrets,
+		variables:     variables,
+		alert:         alert,
+		auditService:  auditService,
 	}
 }
 
-func (t *TriggerService) GetTriggers() []entities.Trigger {
-	return t.repository.FindAll()
+func (t *TriggerService) GetTriggers(projectId uint) []entities.Trigger {
+	triggers := t.repository.FindByProjectId(projectId)
+	for index, trigger := range triggers {
+		triggers[index] = trigger.Masked()
+	}
+
+	return triggers
 }
 
 func (t *TriggerService) GetExecutionsByTriggerId(triggerId string) []entities.Execution {
@@ -63,46 +102,401 @@ func (t *TriggerService) GetExecutionLogsByTriggerIdAndExecutionId(
 	)
 }
 
-func (t *TriggerService) Save(trigger types.Trigger) (string, error) {
+func (t *TriggerService) getWorkflowLabels(trigger types.Trigger) ([]string, error) {
+	content, err := t.github.GetFileContent(
+		trigger.LinkRepository,
+		fmt.Sprintf(".github/workflows/%s", trigger.ActionToRun),
+		"",
+	)
+	if err != nil {
+		// Without the Github App the private repositories, cloned using a
+		// deploy key, can't be read by the api.
+		if trigger.IsPrivate && !t.github.HasApp() {
+			return []string{}, fmt.Errorf(
+				"The runs-on labels of workflow %s weren't read because the repository is private and the Github App isn't configured, set them on field labels",
+				trigger.ActionToRun,
+			)
+		}
+
+		return []string{}, fmt.Errorf(
+			"The runs-on labels of workflow %s weren't read, set them on field labels: %v", trigger.ActionToRun, err,
+		)
+	}
+
+	parsedWorkflow, err := workflow.Parse(content)
+	if err != nil {
+		return []string{}, fmt.Errorf(
+			"The runs-on labels of workflow %s weren't read, set them on field labels: %v", trigger.ActionToRun, err,
+		)
+	}
+
+	return parsedWorkflow.RunnerLabels(), nil
+}
+
+func generateSigningSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+func deployKeySecretName(hash string) string {
+	return fmt.Sprintf("%s-deploy-key", hash)
+}
+
+// labelsOf returns the labels of the trigger with the runs-on labels of the
+// workflow, and the warnings when the workflow couldn't be read.
+func (t *TriggerService) labelsOf(trigger types.Trigger) ([]string, []string) {
+	labels := runner.NormalizeLabels(trigger.Labels)
+	if trigger.Executor == "script" {
+		return labels, nil
+	}
+
+	workflowLabels, err := t.getWorkflowLabels(trigger)
+	if err != nil {
+		t.logger.Warn(err.Error())
+		return labels, []string{err.Error()}
+	}
+
+	return runner.NormalizeLabels(append(labels, workflowLabels...)), nil
+}
+
+// validateLabels validates the labels of the trigger merged with the runs-on
+// labels of the workflow, they are the labels of the queue of the executions.
+func (t *TriggerService) validateLabels(trigger types.Trigger, labels []string) error {
+	if err := runner.ValidateLabels(labels); err != nil {
+		return fmt.Errorf(
+			"The field labels with the runs-on labels of workflow %s are invalid: %w", trigger.ActionToRun, err,
+		)
+	}
+
+	return nil
+}
+
+func (t *TriggerService) Save(trigger types.Trigger, actor types.Actor) (types.NewTrigger, error) {
 	hasEnvs := len(trigger.Envs) > 0
+	labels, warnings := t.labelsOf(trigger)
+	if err := t.validateLabels(trigger, labels); err != nil {
+		return types.NewTrigger{}, err
+	}
+
+	signingSecret, err := generateSigningSecret()
+	if err != nil {
+		t.logger.Error(
+			fmt.Sprintf("Failed to generate signing secret: %v", err),
+		)
+
+		return types.NewTrigger{}, errors.New("Internal server error")
+	}
+
+	encryptedSigningSecret, err := t.encryption.Encrypt(signingSecret)
+	if err != nil {
+		t.logger.Error(
+			fmt.Sprintf("Failed to encrypt signing secret: %v", err),
+		)
+
+		return types.NewTrigger{}, errors.New("Internal server error")
+	}
+
 	triggerToSave := &entities.Trigger{
-		Hash:            trigger.Hash,
-		ActionToRun:     trigger.ActionToRun,
-		LinkRepository:  trigger.LinkRepository,
-		RepositoryToken: trigger.RepositoryToken,
-		IsPrivate:       trigger.IsPrivate,
-		HasEnvs:         hasEnvs,
+		ProjectId:      trigger.ProjectId,
+		Hash:           trigger.Hash,
+		ActionToRun:    trigger.ActionToRun,
+		LinkRepository: trigger.LinkRepository,
+		IsPrivate:      trigger.IsPrivate,
+		HasEnvs:        hasEnvs,
+		Labels:         strings.Join(labels, ","),
+		Resources:      trigger.Resources,
+		Executor:       trigger.Executor,
+		HasDeployKey:   trigger.GenerateDeployKey || len(trigger.DeployKey) > 0,
+		SigningSecret:  encryptedSigningSecret,
+		SecretGroups:   strings.Join(trigger.SecretGroups, ","),
+		Environment:    trigger.Environment,
+	}
+
+	deployKey := trigger.DeployKey
+	if trigger.GenerateDeployKey {
+		privateKey, publicKey, err := sshkey.Generate(fmt.Sprintf("own-githubaction-%s", trigger.Hash))
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Failed to generate deploy key: %v", err),
+			)
+
+			return types.NewTrigger{}, errors.New("Internal server error")
+		}
+
+		deployKey = privateKey
+		triggerToSave.DeployPublicKey = publicKey
 	}
 
 	t.repository.Save(triggerToSave)
+	t.auditService.Record(
+		actor, "trigger.created", triggerToSave.ID, fmt.Sprintf("trigger:%d", triggerToSave.ID),
+		nil, triggerToSave.Masked(),
+	)
+
+	if triggerToSave.HasDeployKey {
+		err := t.secretManager.Add(deployKeySecretName(trigger.Hash), deployKey)
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Failed to create deploy key secret: %v", err),
+			)
+
+			return types.NewTrigger{}, errors.New("Internal server error")
+		}
+	}
 
 	if hasEnvs {
-		envsJSON, _ := json.Marshal(trigger.Envs)
-		err := t.secretManager.Add(trigger.Hash, string(envsJSON))
+		err := t.secrets.SaveSecrets(*triggerToSave, trigger.Envs, actor)
 		if err != nil {
 			t.logger.Error(
 				fmt.Sprintf("Failed to create secret: %v", err),
 			)
 
-			return "", errors.New("Internal server error")
+			return types.NewTrigger{}, errors.New("Internal server error")
 		}
 	}
 
+	for key, value := range trigger.Vars {
+		t.variables.SetVariable(
+			VariableOwner{TriggerId: triggerToSave.ID}, key, value, actor,
+		)
+	}
+
 	apiBaseUrl := os.Getenv("API_BASE_URL")
-	return fmt.Sprintf("%s/triggers-execute/%s", apiBaseUrl, trigger.Hash), nil
+	return types.NewTrigger{
+		ID:              triggerToSave.ID,
+		WebhookUrl:      fmt.Sprintf("%s/triggers-execute/%s", apiBaseUrl, trigger.Hash),
+		GithubSecret:    signingSecret,
+		DeployPublicKey: triggerToSave.DeployPublicKey,
+		Warnings:        warnings,
+	}, nil
+}
+
+// Update changes the workflow, the labels, the resources and the environment
+// of the trigger. The repository and the executor can't be changed.
+func (t *TriggerService) Update(id uint, data types.Trigger, actor types.Actor) (entities.Trigger, error) {
+	trigger := t.repository.FindById(id)
+	if trigger.ID == 0 {
+		return entities.Trigger{}, ErrNotFound
+	}
+
+	validateActionToRun := workflow.ValidateFileName
+	if trigger.Executor == "script" {
+		validateActionToRun = workflow.ValidateScriptPath
+	}
+
+	if len(data.ActionToRun) == 0 && trigger.Executor != "script" {
+		return entities.Trigger{}, errors.New("The field actionToRun is required")
+	}
+
+	if len(data.ActionToRun) > 0 {
+		if err := validateActionToRun(data.ActionToRun); err != nil {
+			return entities.Trigger{}, err
+		}
+	}
+
+	data.LinkRepository = trigger.LinkRepository
+	data.IsPrivate = trigger.IsPrivate
+	data.Executor = trigger.Executor
+	labels, warnings := t.labelsOf(data)
+	if len(warnings) > 0 && len(trigger.Labels) > 0 {
+		// The stored labels have the runs-on labels read before, so the
+		// executions keep going to the runners that have them.
+		labels = runner.NormalizeLabels(append(labels, strings.Split(trigger.Labels, ",")...))
+	}
+
+	if err := t.validateLabels(data, labels); err != nil {
+		return entities.Trigger{}, err
+	}
+
+	t.repository.UpdateSettings(&trigger, entities.Trigger{
+		ActionToRun: data.ActionToRun,
+		Labels:      strings.Join(labels, ","),
+		Resources:   data.Resources,
+		Environment: data.Environment,
+	})
+
+	updated := t.repository.FindById(id)
+	t.auditService.Record(
+		actor, "trigger.updated", trigger.ID, fmt.Sprintf("trigger:%d", trigger.ID),
+		trigger.Masked(), updated.Masked(),
+	)
+
+	return updated.Masked(), nil
+}
+
+// Delete removes the trigger with the secrets and the deploy key, so the
+// webhook and the executions still queued don't run anymore.
+func (t *TriggerService) Delete(id uint, actor types.Actor) error {
+	trigger := t.repository.FindById(id)
+	if trigger.ID == 0 {
+		return ErrNotFound
+	}
+
+	t.secrets.DeleteTriggerSecrets(trigger, actor)
+	if trigger.HasDeployKey {
+		if err := t.secretManager.Delete(deployKeySecretName(trigger.Hash)); err != nil {
+			t.logger.Warn(
+				fmt.Sprintf("Failed to delete deploy key of trigger %d: %v", trigger.ID, err),
+			)
+		}
+	}
+
+	t.repository.Delete(&trigger)
+	t.auditService.Record(
+		actor, "trigger.deleted", trigger.ID, fmt.Sprintf("trigger:%d", trigger.ID),
+		trigger.Masked(), nil,
+	)
+
+	return nil
+}
+
+// GetSigningSecrets returns the secrets accepted to sign the webhook requests of
+// the trigger, including the previous secret during the rotation grace window.
+func (t *TriggerService) GetSigningSecrets(hash string) []string {
+	trigger := t.repository.FindByHash(hash)
+	if trigger.ID == 0 {
+		return []string{}
+	}
+
+	if len(trigger.SigningSecret) == 0 {
+		return []string{trigger.Hash}
+	}
+
+	secrets := []string{}
+	signingSecret, err := t.encryption.Decrypt(trigger.SigningSecret)
+	if err != nil {
+		t.logger.Error(
+			fmt.Sprintf("Failed to decrypt signing secret of trigger %d: %v", trigger.ID, err),
+		)
+		return secrets
+	}
+	secrets = append(secrets, signingSecret)
+
+	if len(trigger.PreviousSigningSecret) > 0 && trigger.PreviousSecretExpiresAt != nil &&
+		time.Now().Before(*trigger.PreviousSecretExpiresAt) {
+		previousSigningSecret, err := t.encryption.Decrypt(trigger.PreviousSigningSecret)
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Failed to decrypt previous signing secret of trigger %d: %v", trigger.ID, err),
+			)
+			return secrets
+		}
+		secrets = append(secrets, previousSigningSecret)
+	}
+
+	return secrets
+}
+
+func (t *TriggerService) RotateSigningSecret(
+	id uint, gracePeriod time.Duration, actor types.Actor,
+) (string, error) {
+	trigger := t.repository.FindById(id)
+	if trigger.ID == 0 {
+		return "", errors.New("Not found register")
+	}
+
+	currentSecret := trigger.SigningSecret
+	if len(currentSecret) == 0 {
+		encryptedHash, err := t.encryption.Encrypt(trigger.Hash)
+		if err != nil {
+			return "", err
+		}
+		currentSecret = encryptedHash
+	}
+
+	signingSecret, err := generateSigningSecret()
+	if err != nil {
+		return "", err
+	}
+
+	encryptedSigningSecret, err := t.encryption.Encrypt(signingSecret)
+	if err != nil {
+		return "", err
+	}
+
+	previousSecretExpiresAt := time.Now().Add(gracePeriod)
+	t.repository.UpdateTriggerData(&trigger, entities.Trigger{
+		SigningSecret:           encryptedSigningSecret,
+		PreviousSigningSecret:   currentSecret,
+		PreviousSecretExpiresAt: &previousSecretExpiresAt,
+	})
+	t.auditService.Record(
+		actor, "trigger.signing_secret_rotated", trigger.ID, fmt.Sprintf("trigger:%d", trigger.ID),
+		trigger.Masked(), t.repository.FindById(id).Masked(),
+	)
+
+	return signingSecret, nil
 }
 
-func (t *TriggerService) Execute(hash string) (entities.Execution, error) {
+func (t *TriggerService) Execute(hash string, event string, body []byte) (entities.Execution, error) {
 	trigger := t.repository.FindByHash(hash)
 
 	if trigger.ID == 0 {
 		return entities.Execution{}, errors.New("Not found register")
 	}
 
+	return t.queueExecution(trigger, event, github.WebhookRef(event, body))
+}
+
+// Run queues an execution of the trigger requested by an user instead of a
+// webhook of Github.
+func (t *TriggerService) Run(id uint, ref string, actor types.Actor) (entities.Execution, error) {
+	trigger := t.repository.FindById(id)
+	if trigger.ID == 0 {
+		return entities.Execution{}, ErrNotFound
+	}
+
+	if len(ref) > 0 && !strings.HasPrefix(ref, "refs/") {
+		ref = "refs/heads/" + ref
+	}
+
+	execution, err := t.queueExecution(trigger, "workflow_dispatch", ref)
+	if err != nil {
+		return execution, err
+	}
+
+	t.auditService.Record(
+		actor, "execution.run", trigger.ID, fmt.Sprintf("execution:%s", execution.ID), nil, execution,
+	)
+	return execution, nil
+}
+
+// Cancel cancels an execution that is still on the queue, the job process
+// skips the executions cancelled.
+func (t *TriggerService) Cancel(
+	triggerId uint, executionId string, actor types.Actor,
+) (entities.Execution, error) {
+	execution := t.repository.FindExecutionById(executionId)
+	if len(execution.ID) == 0 || execution.TriggerId != triggerId {
+		return entities.Execution{}, ErrNotFound
+	}
+
+	if execution.Status != "Queued" {
+		return entities.Execution{}, fmt.Errorf("The execution with status %s can't be cancelled", execution.Status)
+	}
+
+	before := execution
+	t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "Cancelled"})
+	execution.Status = "Cancelled"
+	t.auditService.Record(
+		actor, "execution.cancelled", triggerId, fmt.Sprintf("execution:%s", execution.ID), before, execution,
+	)
+	return execution, nil
+}
+
+func (t *TriggerService) queueExecution(
+	trigger entities.Trigger, event string, ref string,
+) (entities.Execution, error) {
 	execution := entities.Execution{}
 	execution.Status = "Queued"
 	execution.ID = uuid.NewString()
 	execution.TriggerId = trigger.ID
+	execution.Event = event
+	execution.Ref = ref
 
 	t.repository.SaveExecution(&execution)
 
@@ -110,141 +504,313 @@ func (t *TriggerService) Execute(hash string) (entities.Execution, error) {
 		ID:        execution.ID,
 		TriggerId: int(trigger.ID),
 		Status:    execution.Status,
+		Event:     execution.Event,
+		Ref:       execution.Ref,
 		Trigger: types.Trigger{
-			ID:              int(trigger.ID),
-			Hash:            trigger.Hash,
-			ActionToRun:     trigger.ActionToRun,
-			LinkRepository:  fmt.Sprint(trigger.LinkRepository, ".git"),
-			IsPrivate:       trigger.IsPrivate,
-			RepositoryToken: trigger.RepositoryToken,
-			HasEnvs:         trigger.HasEnvs,
+			ID:             int(trigger.ID),
+			ActionToRun:    trigger.ActionToRun,
+			LinkRepository: strings.TrimSuffix(trigger.LinkRepository, ".git") + ".git",
+			IsPrivate:      trigger.IsPrivate,
+			HasEnvs:        trigger.HasEnvs,
+			Labels:         runner.ParseLabels(trigger.Labels),
+			Resources:      trigger.Resources,
+			Executor:       trigger.Executor,
+			HasDeployKey:   trigger.HasDeployKey,
 		},
 	}
 
-	t.producer.Publish(executionMessage)
+	if len(executionMessage.Trigger.Labels) == 0 {
+		t.producer.Publish(executionMessage)
+	} else {
+		t.producer.PublishOnQueue(
+			runner.QueueName(executionMessage.Trigger.Labels), executionMessage,
+		)
+	}
+
 	return execution, nil
 }
 
-func (t *TriggerService) getEnvsDotenvFileFormat(hash string) (string, error) {
-	secret, err := t.secretManager.Get(hash)
-	if err != nil {
-		t.logger.Error(
-			fmt.Sprintf("Failed to get secret: %v", err),
-		)
+var dotenvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"$", `\$`,
+)
 
-		return "", err
+// getEnvsDotenvFileFormat writes the values double quoted with the escapes
+// read by godotenv, the parser used by act.
+func (t *TriggerService) getEnvsDotenvFileFormat(secrets map[string]string) (string, error) {
+	keys := []string{}
+	for key := range secrets {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
 	envs := ""
-	var secretsFromSecretManager map[string]string
-	json.Unmarshal([]byte(secret), &secretsFromSecretManager)
-	for key, value := range secretsFromSecretManager {
-		envs += fmt.Sprintf("%s='%s'\n", key, value)
+	for _, key := range keys {
+		value := secrets[key]
+		// godotenv reads a quote after backslash as escaped quote, so a value
+		// ending with backslash can't be written.
+		if strings.HasSuffix(value, `\`) {
+			return "", fmt.Errorf("the value of %s can't end with backslash", key)
+		}
+
+		envs += fmt.Sprintf("%s=\"%s\"\n", key, dotenvEscaper.Replace(value))
 	}
 
 	return envs, nil
 }
 
+// secretErrorExecution marks the execution as errored when the secrets can't
+// be fetched, so it doesn't stay in the queue retrying without a clear reason.
+func secretErrorExecution(err error) entities.Execution {
+	reason := "failed to get secrets"
+	if errors.Is(err, secretmanager.ErrUnavailable) {
+		reason = "secret backend unavailable"
+	}
+
+	return entities.Execution{Status: "Errored", StatusReason: reason}
+}
+
+// maxDeferrals returns how many times an execution waits the resources it
+// requests before failing, so a request bigger than every job process doesn't
+// stay in the queue forever.
+func maxDeferrals() int {
+	deferrals, err := strconv.Atoi(os.Getenv("MAX_EXECUTION_DEFERRALS"))
+	if err != nil || deferrals < 1 {
+		return 120
+	}
+
+	return deferrals
+}
+
+func (t *TriggerService) deferExecution(p types.Execution) error {
+	execution := t.repository.FindExecutionById(p.ID)
+	if execution.Status == "Cancelled" {
+		t.logger.Info(
+			fmt.Sprintf("Skipped exection with id %s because it was cancelled", p.ID),
+		)
+		return nil
+	}
+
+	if len(execution.ID) > 0 && t.repository.IncrementDeferrals(&execution) >= maxDeferrals() {
+		t.logger.Warn(
+			fmt.Sprintf("Failed exection with id %s because the resources requested were never available", p.ID),
+		)
+		t.repository.UpdateExecutionData(&execution, entities.Execution{
+			Status: "Failed", StatusReason: "resources requested not available on any runner",
+		})
+		return nil
+	}
+
+	t.logger.Info(
+		fmt.Sprintf("Deferred exection with id %s because the resources requested are not available", p.ID),
+	)
+	return fmt.Errorf("resources not available to exection with id %s: %w", p.ID, queue.ErrDeferred)
+}
+
 func (t *TriggerService) ProcessPipeline(payload []byte) error {
 	p := types.Execution{}
 	err := t.queueUtil.ParseMessage(payload, &p)
 	if err != nil {
 		t.logger.Error(
-			fmt.Sprintf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry),
+			fmt.Sprintf("json.Unmarshal failed: %v", err),
+		)
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	release, admitted, err := t.admission.Admit(p.Trigger.Resources)
+	if err != nil {
+		t.logger.Warn(
+			fmt.Sprintf("Failed to check the resources to exection with id %s: %v", p.ID, err),
+		)
+	} else if !admitted {
+		return t.deferExecution(p)
+	} else {
+		defer release()
+	}
+
+	execution := t.repository.FindExecutionById(p.ID)
+	if execution.Status == "Cancelled" {
+		t.logger.Info(
+			fmt.Sprintf("Skipped exection with id %s because it was cancelled", p.ID),
+		)
+		return nil
+	}
+
+	trigger := t.repository.FindById(uint(p.TriggerId))
+	if trigger.ID == 0 {
+		t.logger.Error(
+			fmt.Sprintf("The exection with id %s has the trigger %d that doesn't exist", p.ID, p.TriggerId),
 		)
-		return err
+		t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "Failed"})
+		return nil
 	}
 
-	fileName := fmt.Sprintf("pipelines/.env.%s", p.ID)
+	p.Trigger.Hash = trigger.Hash
+	p.Trigger.HasEnvs = trigger.HasEnvs
 
-	if p.Trigger.HasEnvs {
-		envs, err := t.getEnvsDotenvFileFormat(p.Trigger.Hash)
-		if err != nil {
+	pipelineExecutor, ok := t.executors.Get(p.Trigger.Executor)
+	if !ok {
+		t.logger.Error(
+			fmt.Sprintf("The exection with id %s uses the unknown executor %s", p.ID, p.Trigger.Executor),
+		)
+		t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "Failed"})
+		return nil
+	}
+
+	job := executor.Job{
+		Execution: p,
+		Workspace: filepath.Join("pipelines", p.ID),
+	}
+	defer func() {
+		if err := pipelineExecutor.Cleanup(job); err != nil {
 			t.logger.Error(
-				fmt.Sprintf("Failed to get secret: %v", err),
+				"Error:",
+				zap.Error(fmt.Errorf("%w", err)),
 			)
+		}
+	}()
+
+	secrets, err := t.secrets.GetValues(trigger, SecretScope{
+		Ref:         p.Ref,
+		Event:       p.Event,
+		Environment: trigger.Environment,
+	})
+	if err != nil {
+		t.logger.Error(
+			fmt.Sprintf("Failed to get secret: %v", err),
+		)
+		t.repository.UpdateExecutionData(&execution, secretErrorExecution(err))
+		return nil
+	}
 
-			return err
+	if len(secrets) > 0 {
+		content, err := t.getEnvsDotenvFileFormat(secrets)
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Failed to write secrets of exection with id %s: %v", p.ID, err),
+			)
+			t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "Failed"})
+			return nil
 		}
 
-		err = t.file.WriteFile(fileName, envs)
+		fileName, err := t.file.WriteSecretFile(fmt.Sprintf("env-%s", p.ID), content)
 		if err != nil {
 			t.logger.Error(
 				fmt.Sprintf("Error writing to file: %v", err),
 			)
-			return err
+			t.repository.UpdateExecutionData(&execution, entities.Execution{
+				Status: "Errored", StatusReason: "failed to write secret file",
+			})
+			return nil
 		}
+		// The deferred call also runs when the execution panics, the files of
+		// a worker that crashed are removed when the worker starts again.
+		defer os.Remove(fileName)
 
+		job.SecretFile = fileName
+		job.Secrets = secrets
 	}
 
-	t.logger.Info(
-		fmt.Sprintf(
-			"Start to process exection with id %s the project %s pipeline %s",
-			p.ID,
-			p.Trigger.LinkRepository,
-			p.Trigger.ActionToRun,
-		),
-	)
+	vars := t.variables.GetValues(trigger)
+	if len(vars) > 0 {
+		content, err := t.getEnvsDotenvFileFormat(vars)
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Failed to write vars of exection with id %s: %v", p.ID, err),
+			)
+			t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "Failed"})
+			return nil
+		}
 
-	execution := t.repository.FindExecutionById(p.ID)
-	t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "In Progress"})
+		fileName := fmt.Sprintf("pipelines/.vars.%s", p.ID)
+		err = t.file.WriteFile(fileName, content)
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Error writing to file: %v", err),
+			)
+			t.repository.UpdateExecutionData(&execution, entities.Execution{
+				Status: "Errored", StatusReason: "failed to write vars file",
+			})
+			return nil
+		}
+		defer os.Remove(fileName)
 
-	if p.Trigger.IsPrivate {
-		repositoryLinkSplited := strings.Split(p.Trigger.LinkRepository, "/")
-		githubUser := repositoryLinkSplited[3]
-		repository := repositoryLinkSplited[len(repositoryLinkSplited)-1]
-		p.Trigger.LinkRepository = fmt.Sprintf("%s//%s:%s@github.com/%s/%s",
-			repositoryLinkSplited[0], githubUser, p.Trigger.RepositoryToken, githubUser, repository,
-		)
+		job.VarFile = fileName
+		job.Vars = vars
 	}
 
-	commandsToExecute := "cd pipelines && git clone %s %s && cd %s && act -W '.github/workflows/%s'"
-	paramsCommand := []interface{}{
-		p.Trigger.LinkRepository,
-		p.ID,
-		p.ID,
-		p.Trigger.ActionToRun,
+	if p.Trigger.IsPrivate && !p.Trigger.HasDeployKey {
+		job.RepositoryToken, err = t.github.GetInstallationToken(p.Trigger.LinkRepository)
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Failed to get Github App installation token: %v", err),
+			)
+			t.repository.UpdateExecutionData(&execution, entities.Execution{
+				Status: "Errored", StatusReason: "failed to get Github App installation token",
+			})
+			return nil
+		}
 	}
 
-	if p.Trigger.HasEnvs {
-		commandsToExecute += " --secret-file ../%s"
-		paramsCommand = append(paramsCommand, fmt.Sprintf(".env.%s", p.ID))
+	if p.Trigger.HasDeployKey {
+		deployKey, err := t.secretManager.Get(deployKeySecretName(p.Trigger.Hash))
+		if err != nil {
+			t.logger.Error(
+				fmt.Sprintf("Failed to get deploy key: %v", err),
+			)
+			t.repository.UpdateExecutionData(&execution, secretErrorExecution(err))
+			return nil
+		}
+
+		job.DeployKey = deployKey
 	}
 
-	cmd := exec.Command(
-		"bash", "-c",
+	t.logger.Info(
 		fmt.Sprintf(
-			commandsToExecute,
-			paramsCommand...,
+			"Start to process exection with id %s the project %s pipeline %s",
+			p.ID,
+			p.Trigger.LinkRepository,
+			p.Trigger.ActionToRun,
 		),
 	)
 
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		fmt.Println("Error:", err)
-		return nil
-	}
-
-	if err := cmd.Start(); err != nil {
-		fmt.Println("Error:", err)
-		return nil
-	}
+	t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "In Progress"})
 
-	scanner := bufio.NewScanner(stdoutPipe)
-	for scanner.Scan() {
-		line := scanner.Text()
-		executionLog := entities.ExecutionLog{}
-		executionLog.ExecutionId = p.ID
-		executionLog.Log = line
-		executionLog.ID = uuid.NewString()
-		t.repository.SaveExecutionLog(&executionLog)
+	redactValues := []string{job.RepositoryToken, job.DeployKey}
+	for _, value := range job.Secrets {
+		redactValues = append(redactValues, value)
 	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Println("Error reading output:", err)
+	redactor := logger.NewRedactor(redactValues...)
+	leakScanner := logger.NewLeakScanner()
+	leakSuspected := false
+
+	err = pipelineExecutor.Prepare(job)
+	if err == nil {
+		err = pipelineExecutor.Run(job, func(line string) {
+			executionLog := entities.ExecutionLog{}
+			executionLog.ExecutionId = p.ID
+			log, leaks := leakScanner.Scan(redactor.Redact(logger.Scrub(line)))
+			if len(leaks) > 0 && !leakSuspected {
+				leakSuspected = true
+				t.repository.UpdateExecutionData(&execution, entities.Execution{SecretLeakSuspected: true})
+				t.alert.Send(alert.Event{
+					Type:        "secret_leak_suspected",
+					ExecutionId: p.ID,
+					TriggerId:   p.TriggerId,
+					Details:     leaks,
+				})
+			}
+
+			executionLog.Log = log
+			executionLog.ID = uuid.NewString()
+			t.repository.SaveExecutionLog(&executionLog)
+		})
 	}
 
-	if err := cmd.Wait(); err != nil {
+	if err != nil {
 		t.logger.Error(
 			fmt.Sprintf(
 				"The process exection with id %s the project %s pipeline %s was failed. Caused by: %s",
@@ -267,32 +833,6 @@ func (t *TriggerService) ProcessPipeline(payload []byte) error {
 		t.repository.UpdateExecutionData(&execution, entities.Execution{Status: "Done"})
 	}
 
-	finalCommandsToExecute := "cd pipelines && rm -r %s"
-	paramsFinalCommand := []interface{}{
-		p.ID,
-	}
-
-	if p.Trigger.HasEnvs {
-		finalCommandsToExecute += " && cd .. && rm -f ./%s"
-		paramsFinalCommand = append(paramsFinalCommand, fileName)
-	}
-
-	cmd = exec.Command(
-		"bash", "-c",
-		fmt.Sprintf(
-			finalCommandsToExecute,
-			paramsFinalCommand...,
-		),
-	)
-
-	_, err = cmd.CombinedOutput()
-	if err != nil {
-		t.logger.Error(
-			"Error:",
-			zap.Error(fmt.Errorf("%w", err)),
-		)
-	}
-
 	t.logger.Info(
 		fmt.Sprintf(
 			"Finished to process exection with id %s the project %s pipeline %s",
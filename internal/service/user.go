@@ -0,0 +1,484 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+const (
+	RoleAdmin        = "admin"
+	RoleMaintainer   = "maintainer"
+	RoleViewer       = "viewer"
+	RoleTriggerOwner = "trigger-owner"
+)
+
+const (
+	PermissionTriggersRead     = "triggers:read"
+	PermissionTriggersCreate   = "triggers:create"
+	PermissionTriggersWrite    = "triggers:write"
+	PermissionExecutionsRun    = "executions:run"
+	PermissionExecutionsCancel = "executions:cancel"
+	PermissionLogsRead         = "logs:read"
+	PermissionSecretsRead      = "secrets:read"
+	PermissionSecretsWrite     = "secrets:write"
+	PermissionMembersWrite     = "members:write"
+	PermissionUsersWrite       = "users:write"
+	PermissionAuditRead        = "audit:read"
+	PermissionProjectsWrite    = "projects:write"
+)
+
+var readPermissions = []string{
+	PermissionTriggersRead, PermissionLogsRead,
+}
+
+var maintainPermissions = append([]string{
+	PermissionTriggersWrite, PermissionExecutionsRun, PermissionExecutionsCancel,
+	PermissionSecretsRead, PermissionSecretsWrite,
+}, readPermissions...)
+
+// rolePermissions are the permissions of the role on every trigger of the
+// projects the user is member of. The admins have them on every project.
+var rolePermissions = map[string][]string{
+	RoleAdmin: append([]string{
+		PermissionTriggersCreate, PermissionMembersWrite, PermissionUsersWrite,
+		PermissionAuditRead, PermissionProjectsWrite,
+	}, maintainPermissions...),
+	RoleMaintainer: append([]string{
+		PermissionTriggersCreate, PermissionMembersWrite,
+	}, maintainPermissions...),
+	RoleViewer:       readPermissions,
+	RoleTriggerOwner: {PermissionTriggersCreate},
+}
+
+// memberPermissions are the permissions of the role on the triggers the user
+// is member of.
+var memberPermissions = map[string][]string{
+	RoleTriggerOwner: append([]string{PermissionMembersWrite}, maintainPermissions...),
+	RoleMaintainer:   maintainPermissions,
+	RoleViewer:       readPermissions,
+}
+
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@+-]{1,64}$`)
+
+var ErrUnauthenticated = errors.New("invalid api key")
+
+var ErrForbidden = errors.New("forbidden")
+
+// apiTokenPrefix identifies the api tokens, so they are found on logs and
+// aren't confused with the api keys of the users.
+const apiTokenPrefix = "oga_"
+
+const maxApiTokenDays = 365
+
+func ValidateRole(role string) error {
+	if _, ok := rolePermissions[role]; !ok {
+		return fmt.Errorf("The role %s must be admin, maintainer, viewer or trigger-owner", role)
+	}
+
+	return nil
+}
+
+func ValidateMemberRole(role string) error {
+	if _, ok := memberPermissions[role]; !ok {
+		return fmt.Errorf("The role %s must be trigger-owner, maintainer or viewer", role)
+	}
+
+	return nil
+}
+
+func hasPermission(permissions []string, permission string) bool {
+	for _, value := range permissions {
+		if value == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hashApiKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func generateApiKey() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+type UserService struct {
+	repository         repository.IUserRepository
+	triggerRepository  repository.ITriggerRepository
+	apiTokenRepository repository.IApiTokenRepository
+	projectRepository  repository.IProjectRepository
+	auditService       *AuditService
+}
+
+func NewUserService(
+	repository repository.IUserRepository,
+	triggerRepository repository.ITriggerRepository,
+	apiTokenRepository repository.IApiTokenRepository,
+	projectRepository repository.IProjectRepository,
+	auditService *AuditService,
+) *UserService {
+	return &UserService{
+		repository:         repository,
+		triggerRepository:  triggerRepository,
+		apiTokenRepository: apiTokenRepository,
+		projectRepository:  projectRepository,
+		auditService:       auditService,
+	}
+}
+
+// Authenticate returns the user of the api key or api token. The API_KEY env
+// is the key of a built-in admin, used to create the first users.
+func (u *UserService) Authenticate(apiKey string, ip string) (entities.User, error) {
+	if len(apiKey) == 0 {
+		return entities.User{}, ErrUnauthenticated
+	}
+
+	if strings.HasPrefix(apiKey, apiTokenPrefix) {
+		return u.authenticateApiToken(apiKey, ip)
+	}
+
+	bootstrapApiKey := os.Getenv("API_KEY")
+	if len(bootstrapApiKey) > 0 &&
+		subtle.ConstantTimeCompare([]byte(bootstrapApiKey), []byte(apiKey)) == 1 {
+		return entities.User{Name: "api-key", Role: RoleAdmin}, nil
+	}
+
+	user := u.repository.FindByApiKeyHash(hashApiKey(apiKey))
+	if user.ID == 0 || user.Disabled {
+		return entities.User{}, ErrUnauthenticated
+	}
+
+	return user, nil
+}
+
+func (u *UserService) authenticateApiToken(token string, ip string) (entities.User, error) {
+	apiToken := u.apiTokenRepository.FindByHash(hashApiKey(token))
+	if apiToken.ID == 0 || time.Now().After(apiToken.ExpiresAt) {
+		return entities.User{}, ErrUnauthenticated
+	}
+
+	if len(apiToken.Cidr) > 0 {
+		_, network, err := net.ParseCIDR(apiToken.Cidr)
+		if err != nil || !network.Contains(net.ParseIP(ip)) {
+			return entities.User{}, ErrUnauthenticated
+		}
+	}
+
+	user := u.repository.FindById(apiToken.UserId)
+	if user.ID == 0 || user.Disabled {
+		return entities.User{}, ErrUnauthenticated
+	}
+
+	u.apiTokenRepository.UpdateLastUsedAt(&apiToken, time.Now())
+	user.TokenScopes = strings.Split(apiToken.Scopes, ",")
+	return user, nil
+}
+
+// Can checks the permission of the user on the project, or on the trigger when
+// triggerId isn't 0. The trigger must belong to the project. The admins have
+// the permission on every project, the other users need to be member of the
+// project or of the trigger. The projectId 0 checks the permissions out of the
+// projects, like manage the users. The user authenticated by an api token also
+// needs the permission on the scopes of the token.
+func (u *UserService) Can(user entities.User, permission string, projectId uint, triggerId uint) bool {
+	if user.TokenScopes != nil && !hasPermission(user.TokenScopes, permission) {
+		return false
+	}
+
+	if projectId != 0 && u.projectRepository.FindById(projectId).ID == 0 {
+		return false
+	}
+
+	if triggerId != 0 && u.triggerRepository.FindById(triggerId).ProjectId != projectId {
+		return false
+	}
+
+	if user.Role == RoleAdmin {
+		return hasPermission(rolePermissions[RoleAdmin], permission)
+	}
+
+	if projectId == 0 || user.ID == 0 {
+		return false
+	}
+
+	if hasPermission(rolePermissions[user.Role], permission) &&
+		u.projectRepository.FindMember(projectId, user.ID).ID != 0 {
+		return true
+	}
+
+	if triggerId == 0 {
+		return false
+	}
+
+	member := u.repository.FindMember(triggerId, user.ID)
+	return member.ID != 0 && hasPermission(memberPermissions[member.Role], permission)
+}
+
+func (u *UserService) GetUsers() []entities.User {
+	return u.repository.FindAll()
+}
+
+func (u *UserService) CreateUser(data types.User, actor types.Actor) (types.NewUser, error) {
+	if !userNamePattern.MatchString(data.Name) {
+		return types.NewUser{}, fmt.Errorf("The name %s must have only letters, numbers and the characters _ . @ + -", data.Name)
+	}
+
+	if err := ValidateRole(data.Role); err != nil {
+		return types.NewUser{}, err
+	}
+
+	if u.repository.FindByName(data.Name).ID != 0 {
+		return types.NewUser{}, fmt.Errorf("The user %s already exists", data.Name)
+	}
+
+	apiKey, err := generateApiKey()
+	if err != nil {
+		return types.NewUser{}, err
+	}
+
+	user := entities.User{
+		Name:       data.Name,
+		Role:       data.Role,
+		ApiKeyHash: hashApiKey(apiKey),
+	}
+	u.repository.Save(&user)
+	u.auditService.Record(actor, "user.created", 0, fmt.Sprintf("user:%d", user.ID), nil, user)
+
+	return types.NewUser{
+		ID:     user.ID,
+		Name:   user.Name,
+		Role:   user.Role,
+		ApiKey: apiKey,
+	}, nil
+}
+
+func (u *UserService) UpdateRole(id uint, role string, actor types.Actor) (entities.User, error) {
+	user := u.repository.FindById(id)
+	if user.ID == 0 {
+		return entities.User{}, ErrNotFound
+	}
+
+	if err := ValidateRole(role); err != nil {
+		return entities.User{}, err
+	}
+
+	before := user
+	u.repository.UpdateRole(&user, role)
+	user.Role = role
+	u.auditService.Record(actor, "user.role_updated", 0, fmt.Sprintf("user:%d", user.ID), before, user)
+	return user, nil
+}
+
+// RotateApiKey replaces the api key of the user, the previous key stops to
+// work immediately.
+func (u *UserService) RotateApiKey(id uint, actor types.Actor) (string, error) {
+	user := u.repository.FindById(id)
+	if user.ID == 0 {
+		return "", ErrNotFound
+	}
+
+	apiKey, err := generateApiKey()
+	if err != nil {
+		return "", err
+	}
+
+	u.repository.UpdateApiKeyHash(&user, hashApiKey(apiKey))
+	u.auditService.Record(actor, "user.api_key_rotated", 0, fmt.Sprintf("user:%d", user.ID), nil, nil)
+	return apiKey, nil
+}
+
+func (u *UserService) DeleteUser(id uint, actor types.Actor) error {
+	user := u.repository.FindById(id)
+	if user.ID == 0 {
+		return ErrNotFound
+	}
+
+	u.repository.Delete(&user)
+	u.auditService.Record(actor, "user.deleted", 0, fmt.Sprintf("user:%d", user.ID), user, nil)
+	return nil
+}
+
+func (u *UserService) GetMembers(triggerId uint) ([]entities.TriggerMember, error) {
+	if u.triggerRepository.FindById(triggerId).ID == 0 {
+		return nil, ErrNotFound
+	}
+
+	return u.repository.FindMembers(triggerId), nil
+}
+
+func (u *UserService) SetMember(
+	triggerId uint, userId uint, role string, actor types.Actor,
+) (entities.TriggerMember, error) {
+	if u.triggerRepository.FindById(triggerId).ID == 0 || u.repository.FindById(userId).ID == 0 {
+		return entities.TriggerMember{}, ErrNotFound
+	}
+
+	if err := ValidateMemberRole(role); err != nil {
+		return entities.TriggerMember{}, err
+	}
+
+	member := u.repository.FindMember(triggerId, userId)
+	var before interface{}
+	if member.ID != 0 {
+		before = member
+	}
+
+	member.TriggerId = triggerId
+	member.UserId = userId
+	member.Role = role
+	u.repository.SaveMember(&member)
+	u.auditService.Record(
+		actor, "member.updated", triggerId, fmt.Sprintf("trigger:%d/member:%d", triggerId, userId), before, member,
+	)
+
+	return member, nil
+}
+
+func (u *UserService) DeleteMember(triggerId uint, userId uint, actor types.Actor) error {
+	member := u.repository.FindMember(triggerId, userId)
+	if member.ID == 0 {
+		return ErrNotFound
+	}
+
+	u.repository.DeleteMember(&member)
+	u.auditService.Record(
+		actor, "member.deleted", triggerId, fmt.Sprintf("trigger:%d/member:%d", triggerId, userId), member, nil,
+	)
+	return nil
+}
+
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("The field scopes is required")
+	}
+
+	for _, scope := range scopes {
+		if !hasPermission(rolePermissions[RoleAdmin], scope) {
+			return fmt.Errorf("The scope %s doesn't exist", scope)
+		}
+	}
+
+	return nil
+}
+
+// CreateApiToken creates a token of the user authenticated, or of other user
+// when the user authenticated is an admin. A token can't create other tokens.
+func (u *UserService) CreateApiToken(
+	authenticated entities.User, data types.ApiToken, actor types.Actor,
+) (types.NewApiToken, error) {
+	if authenticated.TokenScopes != nil {
+		return types.NewApiToken{}, ErrForbidden
+	}
+
+	userId := authenticated.ID
+	if data.UserId != 0 && data.UserId != authenticated.ID {
+		if !u.Can(authenticated, PermissionUsersWrite, 0, 0) {
+			return types.NewApiToken{}, ErrForbidden
+		}
+
+		userId = data.UserId
+	}
+
+	if userId == 0 || u.repository.FindById(userId).ID == 0 {
+		return types.NewApiToken{}, ErrNotFound
+	}
+
+	if len(data.Name) == 0 {
+		return types.NewApiToken{}, errors.New("The field name is required")
+	}
+
+	if err := ValidateScopes(data.Scopes); err != nil {
+		return types.NewApiToken{}, err
+	}
+
+	if data.ExpiresInDays <= 0 || data.ExpiresInDays > maxApiTokenDays {
+		return types.NewApiToken{}, fmt.Errorf("The field expiresInDays must be between 1 and %d", maxApiTokenDays)
+	}
+
+	if len(data.Cidr) > 0 {
+		if _, _, err := net.ParseCIDR(data.Cidr); err != nil {
+			return types.NewApiToken{}, fmt.Errorf("The field cidr %s is invalid", data.Cidr)
+		}
+	}
+
+	secret, err := generateApiKey()
+	if err != nil {
+		return types.NewApiToken{}, err
+	}
+
+	token := apiTokenPrefix + secret
+	apiToken := entities.ApiToken{
+		UserId:    userId,
+		Name:      data.Name,
+		TokenHash: hashApiKey(token),
+		Scopes:    strings.Join(data.Scopes, ","),
+		Cidr:      data.Cidr,
+		ExpiresAt: time.Now().AddDate(0, 0, data.ExpiresInDays),
+	}
+	u.apiTokenRepository.Save(&apiToken)
+	u.auditService.Record(
+		actor, "api_token.created", 0, fmt.Sprintf("user:%d/api-token:%d", userId, apiToken.ID), nil, apiToken,
+	)
+
+	return types.NewApiToken{
+		ID:        apiToken.ID,
+		Name:      apiToken.Name,
+		Scopes:    data.Scopes,
+		ExpiresAt: apiToken.ExpiresAt,
+		Token:     token,
+	}, nil
+}
+
+// GetApiTokens lists the tokens of the user authenticated. Like creating, a
+// token can't list the tokens.
+func (u *UserService) GetApiTokens(authenticated entities.User) ([]entities.ApiToken, error) {
+	if authenticated.TokenScopes != nil {
+		return nil, ErrForbidden
+	}
+
+	return u.apiTokenRepository.FindByUserId(authenticated.ID), nil
+}
+
+// DeleteApiToken revokes the token, the admins can revoke the tokens of every
+// user. A token can't revoke the tokens.
+func (u *UserService) DeleteApiToken(authenticated entities.User, id uint, actor types.Actor) error {
+	if authenticated.TokenScopes != nil {
+		return ErrForbidden
+	}
+
+	apiToken := u.apiTokenRepository.FindById(id)
+	if apiToken.ID == 0 {
+		return ErrNotFound
+	}
+
+	if apiToken.UserId != authenticated.ID && !u.Can(authenticated, PermissionUsersWrite, 0, 0) {
+		return ErrNotFound
+	}
+
+	u.apiTokenRepository.Delete(&apiToken)
+	u.auditService.Record(
+		actor, "api_token.revoked", 0, fmt.Sprintf("user:%d/api-token:%d", apiToken.UserId, apiToken.ID), apiToken, nil,
+	)
+	return nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+func TestApiTokenCantManageTokens(t *testing.T) {
+	users := newTestUserService(newTestDB(t))
+	actor := types.Actor{Name: "admin"}
+	newUser, err := users.CreateUser(types.User{Name: "deploy-bot", Role: RoleMaintainer}, actor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := users.Authenticate(newUser.ApiKey, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, err := users.CreateApiToken(user, types.ApiToken{
+		Name: "deploy", Scopes: []string{PermissionExecutionsRun}, ExpiresInDays: 1,
+	}, actor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenUser, err := users.Authenticate(newToken.Token, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := users.GetApiTokens(tokenUser); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected a token can't list the tokens, got %v", err)
+	}
+
+	if err := users.DeleteApiToken(tokenUser, newToken.ID, actor); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected a token can't revoke the tokens, got %v", err)
+	}
+
+	if _, err := users.CreateApiToken(tokenUser, types.ApiToken{
+		Name: "other", Scopes: []string{PermissionExecutionsRun}, ExpiresInDays: 1,
+	}, actor); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected a token can't create tokens, got %v", err)
+	}
+
+	tokens, err := users.GetApiTokens(user)
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("expected the user lists 1 token, got %d %v", len(tokens), err)
+	}
+
+	if err := users.DeleteApiToken(user, newToken.ID, actor); err != nil {
+		t.Errorf("expected the user revokes the token, got %v", err)
+	}
+
+	if _, err := users.Authenticate(newToken.Token, "127.0.0.1"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected the token revoked doesn't authenticate, got %v", err)
+	}
+}
+
+var allPermissions = []string{
+	PermissionTriggersRead, PermissionTriggersCreate, PermissionTriggersWrite,
+	PermissionExecutionsRun, PermissionExecutionsCancel, PermissionLogsRead,
+	PermissionSecretsRead, PermissionSecretsWrite, PermissionMembersWrite,
+	PermissionUsersWrite, PermissionAuditRead, PermissionProjectsWrite,
+}
+
+// authorizationFixture has the projects A and B with one trigger each, and
+// users with each role. The maintainer, the viewer and the owner are members
+// of project A, the owner is also trigger-owner of the trigger of A.
+type authorizationFixture struct {
+	users                                                *UserService
+	projectA, projectB, triggerA, triggerB               uint
+	admin, maintainer, viewer, owner, outsider, follower entities.User
+}
+
+func newAuthorizationFixture(t *testing.T) authorizationFixture {
+	db := newTestDB(t)
+	projects := repository.NewProjectRepository(db)
+	triggers := repository.NewTriggerRepository(db)
+	userRepository := repository.NewUserRepository(db)
+
+	projectA := entities.Project{Name: "a"}
+	projectB := entities.Project{Name: "b"}
+	projects.Save(&projectA)
+	projects.Save(&projectB)
+	triggerA := entities.Trigger{Hash: "a", ProjectId: projectA.ID}
+	triggerB := entities.Trigger{Hash: "b", ProjectId: projectB.ID}
+	triggers.Save(&triggerA)
+	triggers.Save(&triggerB)
+
+	newUser := func(name string, role string) entities.User {
+		user := entities.User{Name: name, Role: role}
+		userRepository.Save(&user)
+		return user
+	}
+	fixture := authorizationFixture{
+		users:      newTestUserService(db),
+		projectA:   projectA.ID,
+		projectB:   projectB.ID,
+		triggerA:   triggerA.ID,
+		triggerB:   triggerB.ID,
+		admin:      newUser("admin", RoleAdmin),
+		maintainer: newUser("maintainer", RoleMaintainer),
+		viewer:     newUser("viewer", RoleViewer),
+		owner:      newUser("owner", RoleTriggerOwner),
+		outsider:   newUser("outsider", RoleMaintainer),
+		follower:   newUser("follower", RoleViewer),
+	}
+
+	for _, user := range []entities.User{fixture.maintainer, fixture.viewer, fixture.owner} {
+		projects.SaveMember(&entities.ProjectMember{ProjectId: projectA.ID, UserId: user.ID})
+	}
+	userRepository.SaveMember(&entities.TriggerMember{TriggerId: triggerA.ID, UserId: fixture.owner.ID, Role: RoleTriggerOwner})
+	userRepository.SaveMember(&entities.TriggerMember{TriggerId: triggerA.ID, UserId: fixture.follower.ID, Role: RoleViewer})
+
+	return fixture
+}
+
+func TestCanRoleMatrix(t *testing.T) {
+	fixture := newAuthorizationFixture(t)
+	maintain := []string{
+		PermissionTriggersRead, PermissionTriggersWrite, PermissionExecutionsRun,
+		PermissionExecutionsCancel, PermissionLogsRead, PermissionSecretsRead, PermissionSecretsWrite,
+	}
+	tests := []struct {
+		name    string
+		user    entities.User
+		allowed []string
+	}{
+		{"admin", fixture.admin, allPermissions},
+		{"maintainer", fixture.maintainer, append([]string{PermissionTriggersCreate, PermissionMembersWrite}, maintain...)},
+		{"viewer", fixture.viewer, []string{PermissionTriggersRead, PermissionLogsRead}},
+		{"trigger-owner", fixture.owner, append([]string{PermissionTriggersCreate, PermissionMembersWrite}, maintain...)},
+		{"trigger viewer", fixture.follower, []string{PermissionTriggersRead, PermissionLogsRead}},
+		{"not member", fixture.outsider, nil},
+	}
+
+	for _, test := range tests {
+		for _, permission := range allPermissions {
+			expected := hasPermission(test.allowed, permission)
+			if can := fixture.users.Can(test.user, permission, fixture.projectA, fixture.triggerA); can != expected {
+				t.Errorf("%s on the trigger: Can(%s) = %v, expected %v", test.name, permission, can, expected)
+			}
+		}
+	}
+
+	projectTests := []struct {
+		name       string
+		user       entities.User
+		permission string
+		projectId  uint
+		expected   bool
+	}{
+		{"maintainer creates on own project", fixture.maintainer, PermissionTriggersCreate, fixture.projectA, true},
+		{"maintainer reads the project", fixture.maintainer, PermissionTriggersRead, fixture.projectA, true},
+		{"trigger-owner creates on own project", fixture.owner, PermissionTriggersCreate, fixture.projectA, true},
+		{"trigger-owner changes the project", fixture.owner, PermissionTriggersWrite, fixture.projectA, false},
+		{"viewer creates", fixture.viewer, PermissionTriggersCreate, fixture.projectA, false},
+		{"trigger viewer reads the project", fixture.follower, PermissionTriggersRead, fixture.projectA, false},
+		{"maintainer creates on other project", fixture.maintainer, PermissionTriggersCreate, fixture.projectB, false},
+		{"admin creates on other project", fixture.admin, PermissionTriggersCreate, fixture.projectB, true},
+		{"admin manages users", fixture.admin, PermissionUsersWrite, 0, true},
+		{"admin reads audit", fixture.admin, PermissionAuditRead, 0, true},
+		{"maintainer manages users", fixture.maintainer, PermissionUsersWrite, 0, false},
+		{"maintainer reads out of projects", fixture.maintainer, PermissionTriggersRead, 0, false},
+	}
+
+	for _, test := range projectTests {
+		if can := fixture.users.Can(test.user, test.permission, test.projectId, 0); can != test.expected {
+			t.Errorf("%s: Can = %v, expected %v", test.name, can, test.expected)
+		}
+	}
+}
+
+func TestCanTokenScopes(t *testing.T) {
+	fixture := newAuthorizationFixture(t)
+	withScopes := func(user entities.User, scopes ...string) entities.User {
+		user.TokenScopes = append([]string{}, scopes...)
+		return user
+	}
+
+	tests := []struct {
+		name       string
+		user       entities.User
+		permission string
+		expected   bool
+	}{
+		{"admin in scope", withScopes(fixture.admin, PermissionTriggersRead), PermissionTriggersRead, true},
+		{"admin out of scope", withScopes(fixture.admin, PermissionTriggersRead), PermissionTriggersWrite, false},
+		{"maintainer in scope", withScopes(fixture.maintainer, PermissionExecutionsRun), PermissionExecutionsRun, true},
+		{"maintainer out of scope", withScopes(fixture.maintainer, PermissionExecutionsRun), PermissionSecretsRead, false},
+		{"scope above the role", withScopes(fixture.viewer, PermissionExecutionsRun), PermissionExecutionsRun, false},
+		{"scope above the member role", withScopes(fixture.follower, PermissionSecretsWrite), PermissionSecretsWrite, false},
+		{"without scopes", withScopes(fixture.admin), PermissionTriggersRead, false},
+		{"not member in scope", withScopes(fixture.outsider, PermissionTriggersRead), PermissionTriggersRead, false},
+	}
+
+	for _, test := range tests {
+		if can := fixture.users.Can(test.user, test.permission, fixture.projectA, fixture.triggerA); can != test.expected {
+			t.Errorf("%s: Can = %v, expected %v", test.name, can, test.expected)
+		}
+	}
+}
+
+func TestCanCrossProject(t *testing.T) {
+	fixture := newAuthorizationFixture(t)
+	tests := []struct {
+		name      string
+		user      entities.User
+		projectId uint
+		triggerId uint
+		expected  bool
+	}{
+		{"maintainer on own trigger", fixture.maintainer, fixture.projectA, fixture.triggerA, true},
+		{"maintainer through other project", fixture.maintainer, fixture.projectB, fixture.triggerA, false},
+		{"maintainer on trigger of other project", fixture.maintainer, fixture.projectA, fixture.triggerB, false},
+		{"trigger member through other project", fixture.follower, fixture.projectB, fixture.triggerA, false},
+		{"admin through other project", fixture.admin, fixture.projectB, fixture.triggerA, false},
+		{"admin on project that doesn't exist", fixture.admin, 999, 0, false},
+		{"admin on trigger that doesn't exist", fixture.admin, fixture.projectA, 999, false},
+		{"admin on trigger without project", fixture.admin, 0, fixture.triggerA, false},
+	}
+
+	for _, test := range tests {
+		if can := fixture.users.Can(test.user, PermissionTriggersRead, test.projectId, test.triggerId); can != test.expected {
+			t.Errorf("%s: Can = %v, expected %v", test.name, can, test.expected)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tiago123456789/own-githubaction/internal/entities"
+	"github.com/tiago123456789/own-githubaction/internal/repository"
+	"github.com/tiago123456789/own-githubaction/internal/types"
+)
+
+var environmentPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+func ValidateEnvironment(environment string) error {
+	if !environmentPattern.MatchString(environment) {
+		return fmt.Errorf("The environment %s must have only letters, numbers, underscore and dash", environment)
+	}
+
+	return nil
+}
+
+// VariableOwner is who the variables belong to: a trigger or an environment
+// of a project.
+type VariableOwner struct {
+	TriggerId   uint
+	ProjectId   uint
+	Environment string
+}
+
+type VariableService struct {
+	repository        repository.IVariableRepository
+	triggerRepository repository.ITriggerRepository
+	auditService      *AuditService
+}
+
+func NewVariableService(
+	repository repository.IVariableRepository,
+	triggerRepository repository.ITriggerRepository,
+	auditService *AuditService,
+) *VariableService {
+	return &VariableService{
+		repository:        repository,
+		triggerRepository: triggerRepository,
+		auditService:      auditService,
+	}
+}
+
+func (o VariableOwner) resource(key string) string {
+	if o.TriggerId != 0 {
+		return fmt.Sprintf("trigger:%d/var:%s", o.TriggerId, key)
+	}
+
+	return fmt.Sprintf("project:%d/environment:%s/var:%s", o.ProjectId, o.Environment, key)
+}
+
+func (v *VariableService) TriggerOwner(projectId uint, triggerId uint) (VariableOwner, error) {
+	trigger := v.triggerRepository.FindById(triggerId)
+	if trigger.ID == 0 || trigger.ProjectId != projectId {
+		return VariableOwner{}, ErrNotFound
+	}
+
+	return VariableOwner{TriggerId: triggerId}, nil
+}
+
+func (v *VariableService) EnvironmentOwner(projectId uint, environment string) (VariableOwner, error) {
+	if err := ValidateEnvironment(environment); err != nil {
+		return VariableOwner{}, ErrNotFound
+	}
+
+	return VariableOwner{ProjectId: projectId, Environment: environment}, nil
+}
+
+func (v *VariableService) GetVariables(owner VariableOwner) []entities.Variable {
+	return v.repository.Find(owner.TriggerId, owner.ProjectId, owner.Environment)
+}
+
+func (v *VariableService) GetHistory(owner VariableOwner) []entities.VariableHistory {
+	return v.repository.FindHistory(owner.TriggerId, owner.ProjectId, owner.Environment)
+}
+
+func (v *VariableService) SetVariable(
+	owner VariableOwner, key string, value string, actor types.Actor,
+) entities.Variable {
+	variable := v.repository.FindByKey(owner.TriggerId, owner.ProjectId, owner.Environment, key)
+	action := "updated"
+	if variable.ID == 0 {
+		action = "created"
+		variable = entities.Variable{
+			TriggerId:   owner.TriggerId,
+			ProjectId:   owner.ProjectId,
+			Environment: owner.Environment,
+			Key:         key,
+		}
+	}
+
+	var before interface{}
+	if variable.ID != 0 {
+		before = variable
+	}
+
+	previousValue := variable.Value
+	variable.Value = value
+	variable.UpdatedBy = actor.Name
+	v.repository.Save(&variable)
+
+	v.repository.SaveHistory(&entities.VariableHistory{
+		TriggerId:     owner.TriggerId,
+		ProjectId:     owner.ProjectId,
+		Environment:   owner.Environment,
+		Key:           key,
+		Action:        action,
+		PreviousValue: previousValue,
+		Value:         value,
+		Actor:         actor.Name,
+		Ip:            actor.Ip,
+	})
+	v.auditService.Record(
+		actor, "variable."+action, owner.TriggerId, owner.resource(key), before, variable,
+	)
+
+	return variable
+}
+
+func (v *VariableService) DeleteVariable(owner VariableOwner, key string, actor types.Actor) error {
+	variable := v.repository.FindByKey(owner.TriggerId, owner.ProjectId, owner.Environment, key)
+	if variable.ID == 0 {
+		return ErrNotFound
+	}
+
+	v.repository.Delete(&variable)
+	v.repository.SaveHistory(&entities.VariableHistory{
+		TriggerId:     owner.TriggerId,
+		ProjectId:     owner.ProjectId,
+		Environment:   owner.Environment,
+		Key:           key,
+		Action:        "deleted",
+		PreviousValue: variable.Value,
+		Actor:         actor.Name,
+		Ip:            actor.Ip,
+	})
+	v.auditService.Record(
+		actor, "variable.deleted", owner.TriggerId, owner.resource(key), variable, nil,
+	)
+
+	return nil
+}
+
+// GetValues returns the variables to use on the execution of the trigger. The
+// variables of the environment on the project of the trigger are overridden by
+// the variables of the trigger.
+func (v *VariableService) GetValues(trigger entities.Trigger) map[string]string {
+	values := map[string]string{}
+	if len(trigger.Environment) > 0 {
+		for _, variable := range v.repository.Find(0, trigger.ProjectId, trigger.Environment) {
+			values[variable.Key] = variable.Value
+		}
+	}
+
+	for _, variable := range v.repository.Find(trigger.ID, 0, "") {
+		values[variable.Key] = variable.Value
+	}
+
+	return values
+}
@@ -0,0 +1,6 @@
+package types
+
+type Actor struct {
+	Name string
+	Ip   string
+}
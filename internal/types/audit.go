@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+type AuditFilter struct {
+	Action    string
+	Actor     string
+	TriggerId uint
+	From      time.Time
+	To        time.Time
+}
+
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type AuditPage struct {
+	Events interface{} `json:"events"`
+	Total  int64       `json:"total"`
+	Page   int         `json:"page"`
+	Limit  int         `json:"limit"`
+}
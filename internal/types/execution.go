@@ -4,5 +4,7 @@ type Execution struct {
 	ID        string
 	TriggerId int
 	Status    string
+	Event     string
+	Ref       string
 	Trigger   Trigger
 }
@@ -1,6 +1,11 @@
 package types
 
 type NewTrigger struct {
-	WebhookUrl   string `json:"webhookUrl"`
-	GithubSecret string `json:"secret"`
+	ID              uint   `json:"id"`
+	WebhookUrl      string `json:"webhookUrl"`
+	GithubSecret    string `json:"secret"`
+	DeployPublicKey string `json:"deployPublicKey,omitempty"`
+	// Warnings are the problems that didn't stop the creation, like the
+	// runs-on labels of a workflow that couldn't be read.
+	Warnings []string `json:"warnings,omitempty"`
 }
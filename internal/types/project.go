@@ -0,0 +1,5 @@
+package types
+
+type Project struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,7 @@
+package types
+
+type Resources struct {
+	MemoryMb int     `json:"memoryMb"`
+	Cpus     float64 `json:"cpus"`
+	DiskMb   int     `json:"diskMb"`
+}
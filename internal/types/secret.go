@@ -0,0 +1,15 @@
+package types
+
+type SecretValue struct {
+	Value string `json:"value"`
+}
+
+type SecretPolicy struct {
+	Branches     []string `json:"branches"`
+	Events       []string `json:"events"`
+	Environments []string `json:"environments"`
+}
+
+type SecretsRotation struct {
+	Secrets map[string]string `json:"secrets"`
+}
@@ -0,0 +1,10 @@
+package types
+
+type SecretGroup struct {
+	Name            string `json:"name"`
+	AllowedTriggers []uint `json:"allowedTriggers"`
+}
+
+type TriggerSecretGroups struct {
+	SecretGroups []string `json:"secretGroups"`
+}
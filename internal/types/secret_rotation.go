@@ -0,0 +1,5 @@
+package types
+
+type SecretRotation struct {
+	GraceMinutes int `json:"graceMinutes"`
+}
@@ -1,12 +1,24 @@
 package types
 
 type Trigger struct {
-	ID              int               `json: "id"`
-	Hash            string            `json:"hash"`
-	ActionToRun     string            `json:"actionToRun"`
-	LinkRepository  string            `json:"linkRepository"`
-	IsPrivate       bool              `json:"isPrivate"`
-	RepositoryToken string            `json:"repositoryToken"`
-	Envs            map[string]string `json:"envs"`
-	HasEnvs         bool              `json:"hasEnvs"`
+	ID             int    `json:"id"`
+	ProjectId      uint   `json:"-"`
+	Hash           string `json:"hash"`
+	ActionToRun    string `json:"actionToRun"`
+	LinkRepository string `json:"linkRepository"`
+	IsPrivate      bool   `json:"isPrivate"`
+	// RepositoryToken is only read to refuse it, the private repositories are
+	// cloned using the Github App or a deploy key.
+	RepositoryToken   string            `json:"repositoryToken"`
+	Envs              map[string]string `json:"envs"`
+	HasEnvs           bool              `json:"hasEnvs"`
+	Labels            []string          `json:"labels"`
+	Resources         Resources         `json:"resources"`
+	Executor          string            `json:"executor"`
+	DeployKey         string            `json:"deployKey"`
+	GenerateDeployKey bool              `json:"generateDeployKey"`
+	HasDeployKey      bool              `json:"hasDeployKey"`
+	SecretGroups      []string          `json:"secretGroups"`
+	Environment       string            `json:"environment"`
+	Vars              map[string]string `json:"vars"`
 }
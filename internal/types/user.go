@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+type User struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type UserRole struct {
+	Role string `json:"role"`
+}
+
+type NewUser struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	ApiKey string `json:"apiKey"`
+}
+
+type ManualRun struct {
+	Ref string `json:"ref"`
+}
+
+type ApiToken struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expiresInDays"`
+	Cidr          string   `json:"cidr"`
+	UserId        uint     `json:"userId"`
+}
+
+type NewApiToken struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Token     string    `json:"token"`
+}
@@ -0,0 +1,5 @@
+package types
+
+type VariableValue struct {
+	Value string `json:"value"`
+}
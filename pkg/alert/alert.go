@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Event struct {
+	Type        string    `json:"type"`
+	ExecutionId string    `json:"executionId"`
+	TriggerId   int       `json:"triggerId"`
+	Details     []string  `json:"details"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type IAlert interface {
+	Send(event Event)
+}
+
+// Alert writes the events on the log, and sends them as a POST request to
+// ALERT_WEBHOOK_URL when it is configured.
+type Alert struct {
+	logger     *zap.Logger
+	webhookUrl string
+	httpClient *http.Client
+}
+
+func New(logger *zap.Logger) *Alert {
+	return &Alert{
+		logger:     logger,
+		webhookUrl: os.Getenv("ALERT_WEBHOOK_URL"),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (a *Alert) Send(event Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	a.logger.Warn(
+		fmt.Sprintf(
+			"Alert %s on exection with id %s of trigger %d: %v",
+			event.Type, event.ExecutionId, event.TriggerId, event.Details,
+		),
+	)
+
+	if len(a.webhookUrl) == 0 {
+		return
+	}
+
+	payload, _ := json.Marshal(event)
+	response, err := a.httpClient.Post(a.webhookUrl, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("Failed to send alert %s: %v", event.Type, err))
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		a.logger.Error(
+			fmt.Sprintf("Failed to send alert %s: the webhook returned status %d", event.Type, response.StatusCode),
+		)
+	}
+}
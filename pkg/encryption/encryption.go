@@ -0,0 +1,255 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+const prefix = "enc:v1:"
+
+type IEncryption interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(value string) (string, error)
+	IsCurrent(value string) bool
+	Rewrap(value string) (string, error)
+}
+
+// Encryption uses envelope encryption: each value is encrypted by one random
+// data key, and the data key is encrypted by the active master key. Rotating
+// the master key only needs to encrypt the data keys again.
+type Encryption struct {
+	keys        map[string][]byte
+	activeKeyId string
+}
+
+func New() *Encryption {
+	keys, err := ParseKeys(os.Getenv("ENCRYPTION_KEYS"))
+	if err != nil {
+		log.Fatalf("Invalid ENCRYPTION_KEYS: %v", err)
+	}
+
+	activeKeyId := os.Getenv("ENCRYPTION_ACTIVE_KEY")
+	encryption, err := NewWithKeys(keys, activeKeyId)
+	if err != nil {
+		log.Fatalf("Failed to initialize encryption: %v", err)
+	}
+
+	return encryption
+}
+
+// ParseKeys reads keys in the format id1:base64key1,id2:base64key2. The first
+// key is the active key when no other is chosen.
+func ParseKeys(value string) ([]Key, error) {
+	keys := []Key{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || strings.Contains(parts[0], ":") {
+			return nil, errors.New("each key must be like id:base64key")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("the key %s isn't base64: %v", parts[0], err)
+		}
+
+		if len(key) != 32 {
+			return nil, fmt.Errorf("the key %s must have 32 bytes", parts[0])
+		}
+
+		keys = append(keys, Key{ID: parts[0], Value: key})
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required, generate one with: " +
+			`echo "ENCRYPTION_KEYS=key1:$(openssl rand -base64 32)" >> .env`)
+	}
+
+	return keys, nil
+}
+
+type Key struct {
+	ID    string
+	Value []byte
+}
+
+func NewWithKeys(keys []Key, activeKeyId string) (*Encryption, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	encryption := &Encryption{
+		keys:        map[string][]byte{},
+		activeKeyId: activeKeyId,
+	}
+	for _, key := range keys {
+		encryption.keys[key.ID] = key.Value
+	}
+
+	if len(encryption.activeKeyId) == 0 {
+		encryption.activeKeyId = keys[0].ID
+	}
+
+	if _, ok := encryption.keys[encryption.activeKeyId]; !ok {
+		return nil, fmt.Errorf("the active key %s doesn't exist", encryption.activeKeyId)
+	}
+
+	return encryption, nil
+}
+
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key []byte, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("the ciphertext is too short")
+	}
+
+	nonce := ciphertext[:gcm.NonceSize()]
+	return gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+}
+
+func (e *Encryption) Encrypt(plaintext string) (string, error) {
+	if len(plaintext) == 0 {
+		return "", nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(e.keys[e.activeKeyId], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"%s%s:%s:%s",
+		prefix,
+		e.activeKeyId,
+		base64.StdEncoding.EncodeToString(wrappedKey),
+		base64.StdEncoding.EncodeToString(ciphertext),
+	), nil
+}
+
+// Decrypt returns the values stored before the encryption existed as they are,
+// so they keep working until the re-encrypt command runs.
+func (e *Encryption) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+
+	dataKey, ciphertext, err := e.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func (e *Encryption) unwrap(value string) ([]byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return nil, nil, errors.New("invalid encrypted value")
+	}
+
+	masterKey, ok := e.keys[parts[0]]
+	if !ok {
+		return nil, nil, fmt.Errorf("the key %s used to encrypt the value isn't configured", parts[0])
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataKey, err := open(masterKey, wrappedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dataKey, ciphertext, nil
+}
+
+// Rewrap encrypts the data key of the value with the active master key, keeping
+// the value encrypted by the same data key.
+func (e *Encryption) Rewrap(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return e.Encrypt(value)
+	}
+
+	dataKey, ciphertext, err := e.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(e.keys[e.activeKeyId], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"%s%s:%s:%s",
+		prefix,
+		e.activeKeyId,
+		base64.StdEncoding.EncodeToString(wrappedKey),
+		base64.StdEncoding.EncodeToString(ciphertext),
+	), nil
+}
+
+func (e *Encryption) IsCurrent(value string) bool {
+	return len(value) == 0 || strings.HasPrefix(value, fmt.Sprintf("%s%s:", prefix, e.activeKeyId))
+}
@@ -0,0 +1,180 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func newKey(t *testing.T, id string) Key {
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		t.Fatal(err)
+	}
+
+	return Key{ID: id, Value: value}
+}
+
+func newEncryption(t *testing.T, keys []Key, activeKeyId string) *Encryption {
+	encryption, err := NewWithKeys(keys, activeKeyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encryption
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	encryption := newEncryption(t, []Key{newKey(t, "key1")}, "")
+
+	for _, plaintext := range []string{"s3cr3t", "multi\nline \"value\" $HOME", strings.Repeat("x", 4096)} {
+		encrypted, err := encryption.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(encrypted, prefix+"key1:") || strings.Contains(encrypted, plaintext) {
+			t.Errorf("expected the value encrypted with key1, got %s", encrypted)
+		}
+
+		decrypted, err := encryption.Decrypt(encrypted)
+		if err != nil || decrypted != plaintext {
+			t.Errorf("expected %q, got %q %v", plaintext, decrypted, err)
+		}
+	}
+
+	first, _ := encryption.Encrypt("s3cr3t")
+	second, _ := encryption.Encrypt("s3cr3t")
+	if first == second {
+		t.Error("expected a new data key and nonce on each encryption")
+	}
+
+	if encrypted, err := encryption.Encrypt(""); err != nil || encrypted != "" {
+		t.Errorf("expected the empty value kept empty, got %q %v", encrypted, err)
+	}
+
+	if decrypted, err := encryption.Decrypt("plaintext-before-encryption"); err != nil || decrypted != "plaintext-before-encryption" {
+		t.Errorf("expected the legacy value returned as it is, got %q %v", decrypted, err)
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	encrypted, err := newEncryption(t, []Key{newKey(t, "key1")}, "").Encrypt("s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newEncryption(t, []Key{newKey(t, "key1")}, "").Decrypt(encrypted); err == nil {
+		t.Error("expected the value refused by other key with the same id")
+	}
+
+	if _, err := newEncryption(t, []Key{newKey(t, "key2")}, "").Decrypt(encrypted); err == nil {
+		t.Error("expected the value refused when its key isn't configured")
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	encryption := newEncryption(t, []Key{newKey(t, "key1")}, "")
+	encrypted, err := encryption.Encrypt("s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(encrypted, prefix), ":")
+	ciphertext, _ := base64.StdEncoding.DecodeString(parts[2])
+	ciphertext[len(ciphertext)-1] ^= 1
+	wrappedKey, _ := base64.StdEncoding.DecodeString(parts[1])
+	wrappedKey[0] ^= 1
+
+	tampered := []string{
+		prefix + parts[0] + ":" + parts[1] + ":" + base64.StdEncoding.EncodeToString(ciphertext),
+		prefix + parts[0] + ":" + base64.StdEncoding.EncodeToString(wrappedKey) + ":" + parts[2],
+		prefix + parts[0] + ":" + parts[1] + ":" + base64.StdEncoding.EncodeToString([]byte("short")),
+		prefix + parts[0] + ":" + parts[1] + ":not-base64!",
+		prefix + parts[0] + ":" + parts[1],
+	}
+	for _, value := range tampered {
+		if _, err := encryption.Decrypt(value); err == nil {
+			t.Errorf("expected the tampered value %s refused", value)
+		}
+	}
+}
+
+func TestRewrapRotatedKey(t *testing.T) {
+	oldKey := newKey(t, "key1")
+	currentKey := newKey(t, "key2")
+	before := newEncryption(t, []Key{oldKey}, "")
+	encrypted, err := before.Encrypt("s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newEncryption(t, []Key{oldKey, currentKey}, "key2")
+	if rotated.IsCurrent(encrypted) {
+		t.Error("expected the value of the previous key not current")
+	}
+
+	if decrypted, err := rotated.Decrypt(encrypted); err != nil || decrypted != "s3cr3t" {
+		t.Errorf("expected the previous key still decrypting, got %q %v", decrypted, err)
+	}
+
+	rewrapped, err := rotated.Rewrap(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rotated.IsCurrent(rewrapped) || !strings.HasPrefix(rewrapped, prefix+"key2:") {
+		t.Errorf("expected the value wrapped by key2, got %s", rewrapped)
+	}
+
+	if encrypted[strings.LastIndex(encrypted, ":"):] != rewrapped[strings.LastIndex(rewrapped, ":"):] {
+		t.Error("expected the rewrap keeping the ciphertext of the data key")
+	}
+
+	onlyNewKey := newEncryption(t, []Key{currentKey}, "")
+	if decrypted, err := onlyNewKey.Decrypt(rewrapped); err != nil || decrypted != "s3cr3t" {
+		t.Errorf("expected the rewrapped value decrypted without the previous key, got %q %v", decrypted, err)
+	}
+
+	if _, err := onlyNewKey.Decrypt(encrypted); err == nil {
+		t.Error("expected the value not rewrapped refused once the previous key is removed")
+	}
+
+	legacy, err := rotated.Rewrap("plaintext-before-encryption")
+	if err != nil || !rotated.IsCurrent(legacy) {
+		t.Errorf("expected the legacy value encrypted by the rewrap, got %s %v", legacy, err)
+	}
+
+	if !rotated.IsCurrent("") {
+		t.Error("expected the empty value current")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	tests := []struct {
+		value string
+		total int
+		valid bool
+	}{
+		{"key1:" + valid, 1, true},
+		{" key1:" + valid + " , key2:" + valid + ",", 2, true},
+		{"", 0, false},
+		{"key1", 0, false},
+		{":" + valid, 0, false},
+		{"key1:not-base64!", 0, false},
+		{"key1:" + base64.StdEncoding.EncodeToString(make([]byte, 16)), 0, false},
+	}
+
+	for _, test := range tests {
+		keys, err := ParseKeys(test.value)
+		if (err == nil) != test.valid || len(keys) != test.total {
+			t.Errorf("ParseKeys(%q) = %d keys %v, expected %d keys valid %v", test.value, len(keys), err, test.total, test.valid)
+		}
+	}
+
+	if _, err := NewWithKeys([]Key{{ID: "key1", Value: make([]byte, 32)}}, "key2"); err == nil {
+		t.Error("expected the active key that doesn't exist refused")
+	}
+}
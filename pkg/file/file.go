@@ -1,13 +1,26 @@
 package file
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"go.uber.org/zap"
 )
 
+const secretFilePrefix = "own-githubaction-"
+
+// currentProcess is the owner written on the secret files of this process.
+var currentProcess = processOwner(os.Getpid())
+
 type IFile interface {
 	WriteFile(fileName string, data string) error
+	WriteSecretFile(name string, data string) (string, error)
+	RemoveStaleSecretFiles()
 }
 
 type File struct {
@@ -24,11 +37,10 @@ func New(
 
 func (f *File) WriteFile(fileName string, data string) error {
 	file, err := os.Create(fileName)
-	defer file.Close()
-
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 
 	_, err = file.WriteString(data)
 	if err != nil {
@@ -37,3 +49,127 @@ func (f *File) WriteFile(fileName string, data string) error {
 
 	return nil
 }
+
+// secretsDir returns where the secret files are written. The default value is
+// /dev/shm, a tmpfs, so the secrets never reach the disk.
+func secretsDir() string {
+	if dir := os.Getenv("SECRETS_DIR"); len(dir) > 0 {
+		return dir
+	}
+
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return "/dev/shm"
+	}
+
+	return os.TempDir()
+}
+
+// WriteSecretFile creates a new file readable only by the owner. The name of
+// the file has the process that wrote it, so the files left by a process that
+// crashed are found by RemoveStaleSecretFiles.
+func (f *File) WriteSecretFile(name string, data string) (string, error) {
+	fileName := filepath.Join(
+		secretsDir(), fmt.Sprintf("%s%s-%s", secretFilePrefix, currentProcess, name),
+	)
+
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.WriteString(data)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(fileName)
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+// RemoveStaleSecretFiles removes the secret files of processes that aren't
+// running anymore.
+func (f *File) RemoveStaleSecretFiles() {
+	files, err := filepath.Glob(filepath.Join(secretsDir(), secretFilePrefix+"*"))
+	if err != nil {
+		return
+	}
+
+	for _, fileName := range files {
+		name := strings.TrimPrefix(filepath.Base(fileName), secretFilePrefix)
+		if isRunning(strings.SplitN(name, "-", 2)[0]) {
+			continue
+		}
+
+		if err := os.Remove(fileName); err != nil {
+			f.logger.Warn(
+				fmt.Sprintf("Failed to remove stale secret file %s: %v", fileName, err),
+			)
+			continue
+		}
+
+		f.logger.Info(fmt.Sprintf("Removed stale secret file %s", fileName))
+	}
+}
+
+// processOwner identifies the process by the pid, the start time and the boot
+// of the host, because the pid alone is used again, like the worker restarted
+// on a container that is pid 1 again. Without /proc it's only the pid.
+func processOwner(pid int) string {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return strconv.Itoa(pid)
+	}
+
+	// The command can have spaces, the fields after it start on the state,
+	// the field 3, and the start time is the field 22.
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) < 20 {
+		return strconv.Itoa(pid)
+	}
+
+	bootId, _ := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	boot := strings.ReplaceAll(strings.TrimSpace(string(bootId)), "-", "")
+	if len(boot) > 8 {
+		boot = boot[:8]
+	}
+
+	return fmt.Sprintf("%d.%s.%s", pid, fields[19], boot)
+}
+
+// isRunning checks if the process that wrote the secret file is still running.
+// The owners that aren't a pid are kept, they weren't written by this code.
+func isRunning(owner string) bool {
+	if owner == currentProcess {
+		return true
+	}
+
+	pid, err := strconv.Atoi(strings.SplitN(owner, ".", 2)[0])
+	if err != nil {
+		return true
+	}
+
+	// The files of other process with the same pid, like this worker before it
+	// restarted, are stale.
+	if pid == os.Getpid() {
+		return false
+	}
+
+	if strings.Contains(owner, ".") {
+		if running := processOwner(pid); strings.Contains(running, ".") {
+			return running == owner
+		}
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
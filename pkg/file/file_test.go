@@ -0,0 +1,56 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRemoveStaleSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SECRETS_DIR", dir)
+	files := New(zap.NewNop())
+
+	current, err := files.WriteSecretFile("env-current", "TOKEN=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid := os.Getpid()
+	parts := strings.SplitN(currentProcess, ".", 2)
+	if len(parts) != 2 {
+		t.Skip("the start time of the process needs /proc")
+	}
+
+	write := func(owner string) string {
+		fileName := filepath.Join(dir, fmt.Sprintf("%s%s-env-1", secretFilePrefix, owner))
+		if err := os.WriteFile(fileName, []byte("TOKEN=1"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return fileName
+	}
+
+	restarted := write(fmt.Sprintf("%d.1.00000000", pid))
+	legacy := write(fmt.Sprint(pid))
+	otherRunning := write(processOwner(os.Getppid()))
+	notRunning := write("999999999.1.00000000")
+	unknown := write("unknown")
+
+	files.RemoveStaleSecretFiles()
+
+	for _, fileName := range []string{current, otherRunning, unknown} {
+		if _, err := os.Stat(fileName); err != nil {
+			t.Errorf("expected %s kept, got %v", filepath.Base(fileName), err)
+		}
+	}
+
+	for _, fileName := range []string{restarted, legacy, notRunning} {
+		if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+			t.Errorf("expected %s removed", filepath.Base(fileName))
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package github
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin renews the installation token before Github expires it,
+// so a clone never starts with a token about to expire.
+const tokenRefreshMargin = 5 * time.Minute
+
+var ErrNoInstallation = errors.New("the Github App isn't installed on the repository")
+
+type responseError struct {
+	status int
+	method string
+	url    string
+}
+
+func (r *responseError) Error() string {
+	return fmt.Sprintf("github returned status %d for %s %s", r.status, r.method, r.url)
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type app struct {
+	id         string
+	privateKey *rsa.PrivateKey
+	mutex      sync.Mutex
+	tokens     map[string]installationToken
+}
+
+func newApp() (*app, error) {
+	id := os.Getenv("GITHUB_APP_ID")
+	if len(id) == 0 {
+		return nil, nil
+	}
+
+	privateKeyPem := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if privateKeyFile := os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"); len(privateKeyFile) > 0 {
+		content, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKeyPem = content
+	}
+
+	block, _ := pem.Decode(privateKeyPem)
+	if block == nil {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY must be a private key in PEM format")
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsedKey, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, err
+		}
+
+		rsaKey, ok := parsedKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("GITHUB_APP_PRIVATE_KEY must be a RSA private key")
+		}
+		privateKey = rsaKey
+	}
+
+	return &app{
+		id:         id,
+		privateKey: privateKey,
+		tokens:     map[string]installationToken{},
+	}, nil
+}
+
+func (a *app) jwt(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.id,
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	hashed := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (g *Github) HasApp() bool {
+	return g.app != nil
+}
+
+func (g *Github) GetInstallationToken(linkRepository string) (string, error) {
+	if g.app == nil {
+		return "", errors.New("the Github App isn't configured")
+	}
+
+	owner, repository, err := ParseRepository(linkRepository)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s/%s", owner, repository)
+
+	g.app.mutex.Lock()
+	defer g.app.mutex.Unlock()
+
+	cached, ok := g.app.tokens[key]
+	if ok && time.Now().Add(tokenRefreshMargin).Before(cached.ExpiresAt) {
+		return cached.Token, nil
+	}
+
+	appJwt, err := g.app.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	installation := struct {
+		ID int64 `json:"id"`
+	}{}
+	err = g.requestJSON(
+		http.MethodGet,
+		fmt.Sprintf("%s/repos/%s/%s/installation", g.baseUrl, owner, repository),
+		appJwt, nil, &installation,
+	)
+	var responseErr *responseError
+	if errors.As(err, &responseErr) && responseErr.status == http.StatusNotFound {
+		return "", ErrNoInstallation
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	body, _ := json.Marshal(map[string][]string{"repositories": {repository}})
+	token := installationToken{}
+	err = g.requestJSON(
+		http.MethodPost,
+		fmt.Sprintf("%s/app/installations/%d/access_tokens", g.baseUrl, installation.ID),
+		appJwt, body, &token,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	g.app.tokens[key] = token
+	return token.Token, nil
+}
+
+func (g *Github) requestJSON(method string, url string, token string, body []byte, result interface{}) error {
+	request, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Accept", "application/vnd.github+json")
+	request.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := g.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &responseError{status: response.StatusCode, method: method, url: url}
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
@@ -0,0 +1,166 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGithub is a local stand-in of the Github api with one repository where
+// the app is installed, acme/private, and one where it isn't, acme/public.
+type fakeGithub struct {
+	t          *testing.T
+	publicKey  *rsa.PublicKey
+	mutex      sync.Mutex
+	tokens     int
+	expiresIn  time.Duration
+	lastTokens []string
+}
+
+func (f *fakeGithub) verifyAppJwt(request *http.Request) bool {
+	parts := strings.Split(strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer "), ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(f.publicKey, crypto.SHA256, hashed[:], signature) != nil {
+		return false
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	claims := map[string]interface{}{}
+	json.Unmarshal(payload, &claims)
+	return claims["iss"] == "123" && int64(claims["exp"].(float64)) > time.Now().Unix()
+}
+
+func (f *fakeGithub) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch {
+	case request.URL.Path == "/repos/acme/private/installation":
+		if !f.verifyAppJwt(request) {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(writer, `{"id": 42}`)
+	case request.URL.Path == "/repos/acme/public/installation":
+		writer.WriteHeader(http.StatusNotFound)
+	case request.URL.Path == "/app/installations/42/access_tokens" && request.Method == http.MethodPost:
+		if !f.verifyAppJwt(request) {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.tokens++
+		token := fmt.Sprintf("ghs_installation%d", f.tokens)
+		json.NewEncoder(writer).Encode(map[string]interface{}{
+			"token":      token,
+			"expires_at": time.Now().Add(f.expiresIn).UTC().Format(time.RFC3339),
+		})
+	case strings.HasPrefix(request.URL.Path, "/repos/acme/private/contents/"):
+		f.lastTokens = append(f.lastTokens, request.Header.Get("Authorization"))
+		if request.Header.Get("Authorization") != fmt.Sprintf("Bearer ghs_installation%d", f.tokens) {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(writer, "private content")
+	case strings.HasPrefix(request.URL.Path, "/repos/acme/public/contents/"):
+		f.lastTokens = append(f.lastTokens, request.Header.Get("Authorization"))
+		fmt.Fprint(writer, "public content")
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestGithub(t *testing.T, expiresIn time.Duration) (*Github, *fakeGithub) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeGithub{t: t, publicKey: &privateKey.PublicKey, expiresIn: expiresIn}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_APP_ID", "123")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY_FILE", "")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", string(pem.EncodeToMemory(&pem.Block{
+		Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})))
+
+	return New(), fake
+}
+
+func TestGetFileContentUsesInstallationToken(t *testing.T) {
+	client, fake := newTestGithub(t, time.Hour)
+
+	for index := 0; index < 2; index++ {
+		content, err := client.GetFileContent("https://github.com/acme/private", ".github/workflows/ci.yml", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != "private content" {
+			t.Errorf("expected the private content, got %s", content)
+		}
+	}
+
+	if fake.tokens != 1 {
+		t.Errorf("expected the installation token cached, got %d tokens created", fake.tokens)
+	}
+}
+
+func TestInstallationTokenRefreshedBeforeExpiring(t *testing.T) {
+	client, fake := newTestGithub(t, tokenRefreshMargin-time.Minute)
+
+	first, err := client.GetInstallationToken("https://github.com/acme/private.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := client.GetInstallationToken("https://github.com/acme/private")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second || fake.tokens != 2 {
+		t.Errorf("expected a new token when the cached one is about to expire, got %s and %s", first, second)
+	}
+}
+
+func TestGetFileContentWithoutInstallation(t *testing.T) {
+	client, fake := newTestGithub(t, time.Hour)
+
+	content, err := client.GetFileContent("https://github.com/acme/public", ".github/workflows/ci.yml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "public content" || fake.lastTokens[0] != "" {
+		t.Errorf("expected the public repository read without token, got %s %v", content, fake.lastTokens)
+	}
+
+	if _, err := client.GetInstallationToken("https://github.com/acme/public"); !errors.Is(err, ErrNoInstallation) {
+		t.Errorf("expected ErrNoInstallation, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ownerPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+var repositoryPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+type IGithub interface {
+	GetFileContent(linkRepository string, path string, token string) ([]byte, error)
+	HasApp() bool
+	GetInstallationToken(linkRepository string) (string, error)
+}
+
+type Github struct {
+	baseUrl    string
+	httpClient *http.Client
+	app        *app
+}
+
+func New() *Github {
+	baseUrl := os.Getenv("GITHUB_API_URL")
+	if len(baseUrl) == 0 {
+		baseUrl = "https://api.github.com"
+	}
+
+	githubApp, err := newApp()
+	if err != nil {
+		log.Fatalf("Failed to initialize Github App: %v", err)
+	}
+
+	return &Github{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		app: githubApp,
+	}
+}
+
+func ParseRepository(linkRepository string) (string, string, error) {
+	link, err := url.Parse(strings.TrimSuffix(linkRepository, ".git"))
+	if err != nil {
+		return "", "", err
+	}
+
+	parts := strings.Split(strings.Trim(link.Path, "/"), "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", errors.New("The repository link must be like https://github.com/owner/repository")
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// ValidateRepositoryLink accepts only links like https://github.com/owner/repository
+// with an optional .git suffix.
+func ValidateRepositoryLink(linkRepository string) error {
+	invalidLinkErr := errors.New("The field linkRepository must be like https://github.com/owner/repository")
+
+	link, err := url.Parse(linkRepository)
+	if err != nil {
+		return invalidLinkErr
+	}
+
+	if link.Scheme != "https" || link.Host != "github.com" || link.User != nil ||
+		len(link.RawQuery) > 0 || len(link.Fragment) > 0 || link.RawPath != "" {
+		return invalidLinkErr
+	}
+
+	parts := strings.Split(strings.TrimPrefix(link.Path, "/"), "/")
+	if len(parts) != 2 {
+		return invalidLinkErr
+	}
+
+	repository := strings.TrimSuffix(parts[1], ".git")
+	if !ownerPattern.MatchString(parts[0]) || !repositoryPattern.MatchString(repository) ||
+		repository == "." || repository == ".." {
+		return invalidLinkErr
+	}
+
+	return nil
+}
+
+func (g *Github) GetFileContent(linkRepository string, path string, token string) ([]byte, error) {
+	owner, repository, err := ParseRepository(linkRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("%s/repos/%s/%s/contents/%s", g.baseUrl, owner, repository, path),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", "application/vnd.github.raw")
+	// The public repositories where the app isn't installed are read without
+	// token, like before the app was configured.
+	if len(token) == 0 && g.HasApp() {
+		token, err = g.GetInstallationToken(linkRepository)
+		if err != nil && !errors.Is(err, ErrNoInstallation) {
+			return nil, err
+		}
+	}
+
+	if len(token) > 0 {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := g.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("github returned status %d for %s", response.StatusCode, path)
+	}
+
+	return io.ReadAll(response.Body)
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type webhookPayload struct {
+	Ref     string `json:"ref"`
+	RefType string `json:"ref_type"`
+	Number  int    `json:"number"`
+	Release struct {
+		TagName string `json:"tag_name"`
+	} `json:"release"`
+}
+
+// WebhookRef returns the git ref of the webhook event on the same format of
+// GITHUB_REF, example refs/heads/main, or empty when the event has no ref.
+func WebhookRef(event string, body []byte) string {
+	payload := webhookPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	switch event {
+	case "pull_request", "pull_request_target", "pull_request_review":
+		if payload.Number > 0 {
+			return fmt.Sprintf("refs/pull/%d/merge", payload.Number)
+		}
+	case "release":
+		if len(payload.Release.TagName) > 0 {
+			return fmt.Sprintf("refs/tags/%s", payload.Release.TagName)
+		}
+	case "create", "delete":
+		if payload.RefType == "tag" {
+			return fmt.Sprintf("refs/tags/%s", payload.Ref)
+		}
+		if len(payload.Ref) > 0 {
+			return fmt.Sprintf("refs/heads/%s", payload.Ref)
+		}
+	}
+
+	if strings.HasPrefix(payload.Ref, "refs/") {
+		return payload.Ref
+	}
+
+	return ""
+}
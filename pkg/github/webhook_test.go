@@ -0,0 +1,29 @@
+package github
+
+import "testing"
+
+func TestWebhookRef(t *testing.T) {
+	tests := []struct {
+		event    string
+		body     string
+		expected string
+	}{
+		{"push", `{"ref": "refs/heads/main"}`, "refs/heads/main"},
+		{"push", `{"ref": "refs/tags/v1"}`, "refs/tags/v1"},
+		{"pull_request", `{"number": 42, "ref": "ignored"}`, "refs/pull/42/merge"},
+		{"pull_request_target", `{"number": 7}`, "refs/pull/7/merge"},
+		{"release", `{"release": {"tag_name": "v1.2.0"}}`, "refs/tags/v1.2.0"},
+		{"create", `{"ref": "v2", "ref_type": "tag"}`, "refs/tags/v2"},
+		{"create", `{"ref": "feature/a", "ref_type": "branch"}`, "refs/heads/feature/a"},
+		{"delete", `{"ref": "old", "ref_type": "branch"}`, "refs/heads/old"},
+		{"workflow_run", `{"ref": "main"}`, ""},
+		{"ping", `{}`, ""},
+		{"push", `invalid`, ""},
+	}
+
+	for _, test := range tests {
+		if ref := WebhookRef(test.event, []byte(test.body)); ref != test.expected {
+			t.Errorf("WebhookRef(%s, %s) = %q, expected %q", test.event, test.body, ref, test.expected)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+type leakPattern struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var leakPatterns = []leakPattern{
+	{"aws_access_key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_key", regexp.MustCompile(`(?i)(aws_secret_access_key|aws_secret_key)(["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`)},
+	{"github_token", regexp.MustCompile(`\b(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b`)},
+	{"github_token", regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+var privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+
+var privateKeyEnd = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+
+var privateKeyBody = regexp.MustCompile(`^[A-Za-z0-9+/=]+$`)
+
+var privateKeyHeader = regexp.MustCompile(`^((Proc-Type|DEK-Info|Comment): .*)?$`)
+
+// maxPrivateKeyLines is the most lines of a private key body, a RSA key of
+// 8192 bits has about 100 lines.
+const maxPrivateKeyLines = 120
+
+// maxPrivateKeyHeaders are the Proc-Type, DEK-Info and the empty line of an
+// encrypted PEM.
+const maxPrivateKeyHeaders = 3
+
+// minPrivateKeyLineLength is the length of the lines of a private key body
+// except the last one, the PEM lines have 64 characters and OpenSSH 70.
+const minPrivateKeyLineLength = 60
+
+// LeakScanner finds well known credentials on the lines of one execution log.
+// It keeps state between lines because a private key is printed on many lines.
+// The state ends at the first line that isn't part of a key body, so a BEGIN
+// line without END, like a truncated output, doesn't hide the rest of the log.
+type LeakScanner struct {
+	insidePrivateKey bool
+	privateKeyLines  int
+	headerLines      int
+	lastLineIsShort  bool
+}
+
+func NewLeakScanner() *LeakScanner {
+	return &LeakScanner{}
+}
+
+// Scan returns the line with the credentials found replaced and the names of
+// the patterns found.
+func (l *LeakScanner) Scan(line string) (string, []string) {
+	found := []string{}
+
+	if l.insidePrivateKey {
+		if location := privateKeyEnd.FindStringIndex(line); location != nil {
+			l.insidePrivateKey = false
+			return redactedValue + line[location[0]:], found
+		}
+
+		if l.isPrivateKeyBody(strings.TrimSpace(line)) {
+			return redactedValue, found
+		}
+
+		l.insidePrivateKey = false
+	}
+
+	if location := privateKeyBegin.FindStringIndex(line); location != nil {
+		found = append(found, "private_key")
+		rest := line[location[1]:]
+		if end := privateKeyEnd.FindStringIndex(rest); end != nil {
+			line = line[:location[1]] + redactedValue + rest[end[0]:]
+		} else {
+			l.insidePrivateKey = true
+			l.privateKeyLines = 0
+			l.headerLines = 0
+			l.lastLineIsShort = false
+			line = line[:location[1]]
+			if len(strings.TrimSpace(rest)) > 0 {
+				line += redactedValue
+			}
+		}
+	}
+
+	for _, leak := range leakPatterns {
+		if !leak.pattern.MatchString(line) {
+			continue
+		}
+
+		found = append(found, leak.name)
+		if leak.name == "aws_secret_key" {
+			line = leak.pattern.ReplaceAllString(line, "${1}${2}"+redactedValue)
+			continue
+		}
+		line = leak.pattern.ReplaceAllString(line, redactedValue)
+	}
+
+	return line, found
+}
+
+// isPrivateKeyBody checks the line continues the key body: the headers of an
+// encrypted PEM before the body, then base64 lines where only the last one is
+// short.
+func (l *LeakScanner) isPrivateKeyBody(line string) bool {
+	if l.privateKeyLines == 0 && l.headerLines < maxPrivateKeyHeaders &&
+		privateKeyHeader.MatchString(line) {
+		l.headerLines++
+		return true
+	}
+
+	if l.lastLineIsShort || l.privateKeyLines >= maxPrivateKeyLines ||
+		!privateKeyBody.MatchString(line) {
+		return false
+	}
+
+	isShort := len(line) < minPrivateKeyLineLength
+	if isShort && l.privateKeyLines == 0 {
+		return false
+	}
+
+	l.privateKeyLines++
+	l.lastLineIsShort = isShort
+	return true
+}
@@ -15,9 +15,9 @@ func Get() *zap.Logger {
 	logFile, _ := os.OpenFile("./logs/log.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	writer := zapcore.AddSync(logFile)
 	defaultLogLevel := zapcore.DebugLevel
-	core := zapcore.NewTee(
+	core := scrubCore{zapcore.NewTee(
 		zapcore.NewCore(fileEncoder, writer, defaultLogLevel),
-	)
+	)}
 	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
 
 	return logger
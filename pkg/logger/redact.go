@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// minRedactLength avoids masking every occurrence of very short values, like
+// "1" or "true", that would make the logs unreadable.
+const minRedactLength = 4
+
+const redactedValue = "***"
+
+// Redactor masks known secret values, and the encoded variants of them, in the
+// lines of the execution logs.
+type Redactor struct {
+	replacer *strings.Replacer
+}
+
+func NewRedactor(values ...string) *Redactor {
+	variants := map[string]bool{}
+	for _, value := range values {
+		for _, variant := range redactVariants(value) {
+			if len(variant) >= minRedactLength {
+				variants[variant] = true
+			}
+		}
+	}
+
+	// The longest values first, so a value containing another is fully masked.
+	sorted := []string{}
+	for variant := range variants {
+		sorted = append(sorted, variant)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	pairs := []string{}
+	for _, variant := range sorted {
+		pairs = append(pairs, variant, redactedValue)
+	}
+
+	return &Redactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+func (r *Redactor) Redact(message string) string {
+	return r.replacer.Replace(message)
+}
+
+func redactVariants(value string) []string {
+	value = strings.TrimSpace(value)
+	variants := []string{
+		value,
+		url.QueryEscape(value),
+		url.PathEscape(value),
+		strings.NewReplacer("\\", `\\`, "\n", `\n`, "\r", `\r`, `"`, `\"`).Replace(value),
+	}
+	variants = append(variants, base64Variants(value)...)
+
+	// A value with many lines, like a private key, is printed on many lines of
+	// the log, so every line is masked too.
+	if strings.Contains(value, "\n") {
+		for _, line := range strings.Split(value, "\n") {
+			variants = append(variants, strings.TrimSpace(line))
+		}
+	}
+
+	return variants
+}
+
+// base64Variants returns the base64 of the value on the 3 possible alignments,
+// without the characters that depend on the bytes around it, so the value is
+// found even inside a bigger encoded text like a basic authorization header.
+func base64Variants(value string) []string {
+	variants := []string{}
+	for offset := 0; offset < 3; offset++ {
+		data := append(make([]byte, offset), value...)
+		start := (offset*8 + 5) / 6
+		end := len(data) * 8 / 6
+		if end <= start {
+			continue
+		}
+
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+			encoded := encoding.EncodeToString(data)
+			variants = append(variants, encoded[start:end])
+		}
+	}
+
+	return variants
+}
@@ -0,0 +1,245 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew accepts tokens of a provider with the clock a little different.
+const clockSkew = time.Minute
+
+// jwksRefreshInterval limits how often an unknown key id fetches the JWKS
+// again, the provider rotates the keys but a forged kid shouldn't flood it.
+const jwksRefreshInterval = time.Minute
+
+type keySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) getKey(kid string) (crypto.PublicKey, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.keys != nil {
+		if key, ok := p.keys.keys[kid]; ok {
+			return key, nil
+		}
+
+		if time.Since(p.keys.fetchedAt) < jwksRefreshInterval {
+			return nil, fmt.Errorf("the key %s isn't on the JWKS", kid)
+		}
+	}
+
+	jwks := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := p.getJSON(p.discovery.JwksUri, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+
+		key, err := parseJsonWebKey(jwk)
+		if err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys = &keySet{keys: keys, fetchedAt: time.Now()}
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("the key %s isn't on the JWKS", kid)
+	}
+
+	return key, nil
+}
+
+func parseJsonWebKey(jwk jsonWebKey) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("the curve %s isn't supported", jwk.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("the key type %s isn't supported", jwk.Kty)
+}
+
+// verify checks the signature of the ID token with the JWKS of the provider
+// and the claims iss, aud, exp and nonce.
+func (p *Provider) verify(idToken string, nonce string, now time.Time) (Claims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("the id_token isn't a JWT")
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, err
+	}
+
+	key, err := p.getKey(header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return Claims{}, fmt.Errorf("the algorithm %s isn't accepted", header.Alg)
+		}
+
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], signature); err != nil {
+			return Claims{}, errors.New("the signature of the id_token is invalid")
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" || len(signature) != 64 {
+			return Claims{}, fmt.Errorf("the algorithm %s isn't accepted", header.Alg)
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, hashed[:], r, s) {
+			return Claims{}, errors.New("the signature of the id_token is invalid")
+		}
+	default:
+		return Claims{}, errors.New("the key of the id_token isn't supported")
+	}
+
+	payload := map[string]interface{}{}
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return Claims{}, err
+	}
+
+	if issuer, _ := payload["iss"].(string); strings.TrimSuffix(issuer, "/") != p.issuer {
+		return Claims{}, fmt.Errorf("the id_token has issuer %s", issuer)
+	}
+
+	if !containsString(stringList(payload["aud"]), p.clientId) {
+		return Claims{}, errors.New("the id_token wasn't issued to this client")
+	}
+
+	expiresAt, _ := payload["exp"].(float64)
+	if now.After(time.Unix(int64(expiresAt), 0).Add(clockSkew)) {
+		return Claims{}, errors.New("the id_token is expired")
+	}
+
+	tokenNonce, _ := payload["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return Claims{}, errors.New("the nonce of the id_token is invalid")
+	}
+
+	claims := Claims{Groups: stringList(payload[p.groupsClaim])}
+	claims.Subject, _ = payload["sub"].(string)
+	claims.Email, _ = payload["email"].(string)
+	claims.Name, _ = payload["name"].(string)
+	if len(claims.Subject) == 0 {
+		return Claims{}, errors.New("the id_token doesn't have the claim sub")
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, result interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// stringList reads a claim that can be a string or a list of strings.
+func stringList(value interface{}) []string {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}
+	case []interface{}:
+		values := []string{}
+		for _, item := range typed {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,198 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Claims struct {
+	Subject string
+	Email   string
+	Name    string
+	Groups  []string
+}
+
+type IProvider interface {
+	Enabled() bool
+	AuthCodeURL(state string, codeChallenge string, nonce string) (string, error)
+	Exchange(code string, codeVerifier string, nonce string) (Claims, error)
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// Provider logs in the users on an OpenID Connect provider using the
+// authorization code flow with PKCE, validating the ID token with the JWKS.
+type Provider struct {
+	issuer       string
+	clientId     string
+	clientSecret string
+	redirectUrl  string
+	scopes       string
+	groupsClaim  string
+	httpClient   *http.Client
+	mutex        sync.Mutex
+	discovery    *discovery
+	keys         *keySet
+}
+
+func New() *Provider {
+	scopes := os.Getenv("OIDC_SCOPES")
+	if len(scopes) == 0 {
+		scopes = "openid email profile groups"
+	}
+
+	groupsClaim := os.Getenv("OIDC_GROUPS_CLAIM")
+	if len(groupsClaim) == 0 {
+		groupsClaim = "groups"
+	}
+
+	return &Provider{
+		issuer:       strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		clientId:     os.Getenv("OIDC_CLIENT_ID"),
+		clientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		redirectUrl:  os.Getenv("OIDC_REDIRECT_URL"),
+		scopes:       scopes,
+		groupsClaim:  groupsClaim,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (p *Provider) Enabled() bool {
+	return len(p.issuer) > 0 && len(p.clientId) > 0
+}
+
+// NewCodeVerifier returns the PKCE code verifier and the S256 challenge of it.
+func NewCodeVerifier() (string, string, error) {
+	verifier, err := RandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+func RandomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func (p *Provider) AuthCodeURL(state string, codeChallenge string, nonce string) (string, error) {
+	config, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientId)
+	query.Set("redirect_uri", p.redirectUrl)
+	query.Set("scope", p.scopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(config.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return config.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+func (p *Provider) Exchange(code string, codeVerifier string, nonce string) (Claims, error) {
+	config, err := p.getDiscovery()
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectUrl)
+	form.Set("client_id", p.clientId)
+	form.Set("code_verifier", codeVerifier)
+	if len(p.clientSecret) > 0 {
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	response, err := p.httpClient.PostForm(config.TokenEndpoint, form)
+	if err != nil {
+		return Claims{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Claims{}, fmt.Errorf("the OIDC provider returned status %d on token request", response.StatusCode)
+	}
+
+	token := struct {
+		IdToken string `json:"id_token"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return Claims{}, err
+	}
+
+	if len(token.IdToken) == 0 {
+		return Claims{}, errors.New("the OIDC provider didn't return an id_token")
+	}
+
+	return p.verify(token.IdToken, nonce, time.Now())
+}
+
+func (p *Provider) getDiscovery() (*discovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	config := &discovery{}
+	if err := p.getJSON(p.issuer+"/.well-known/openid-configuration", config); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(config.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("the OIDC discovery has issuer %s instead of %s", config.Issuer, p.issuer)
+	}
+
+	p.discovery = config
+	return config, nil
+}
+
+func (p *Provider) getJSON(url string, result interface{}) error {
+	response, err := p.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("the OIDC provider returned status %d for %s", response.StatusCode, url)
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/pkg/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	issuer := oidctest.NewIssuer("own-githubaction")
+	t.Cleanup(issuer.Close)
+	issuer.Claims["sub"] = "user-1"
+	issuer.Claims["email"] = "dev@example.com"
+	issuer.Claims["groups"] = []string{"developers"}
+
+	t.Setenv("OIDC_ISSUER", issuer.URL)
+	t.Setenv("OIDC_CLIENT_ID", issuer.ClientId)
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:3000/auth/oidc/callback")
+	return New(), issuer
+}
+
+func login(provider *Provider, issuer *oidctest.Issuer, verifier string) (Claims, error) {
+	codeVerifier, challenge, err := NewCodeVerifier()
+	if err != nil {
+		return Claims{}, err
+	}
+
+	authUrl, err := provider.AuthCodeURL("state", challenge, "nonce")
+	if err != nil {
+		return Claims{}, err
+	}
+
+	code, _, err := issuer.Authorize(authUrl)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if len(verifier) > 0 {
+		codeVerifier = verifier
+	}
+
+	return provider.Exchange(code, codeVerifier, "nonce")
+}
+
+func TestLogin(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+
+	claims, err := login(provider, issuer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "user-1" || claims.Email != "dev@example.com" ||
+		len(claims.Groups) != 1 || claims.Groups[0] != "developers" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestDiscoveryWithOtherIssuer(t *testing.T) {
+	provider, _ := newTestProvider(t)
+	provider.issuer = strings.Replace(provider.issuer, "127.0.0.1", "localhost", 1)
+
+	if _, err := provider.AuthCodeURL("state", "challenge", "nonce"); err == nil ||
+		!strings.Contains(err.Error(), "instead of") {
+		t.Errorf("expected the discovery of other issuer is refused, got %v", err)
+	}
+}
+
+func TestExchangeChecksTheCodeVerifier(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+
+	otherVerifier, _, _ := NewCodeVerifier()
+	if _, err := login(provider, issuer, otherVerifier); err == nil {
+		t.Error("expected the token request with other code verifier fails")
+	}
+}
+
+func TestVerifyRefusesInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(claims map[string]interface{})
+	}{
+		{"issuer", func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" }},
+		{"audience", func(claims map[string]interface{}) { claims["aud"] = []string{"other-client"} }},
+		{"expired", func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-2 * clockSkew).Unix() }},
+		{"nonce", func(claims map[string]interface{}) { claims["nonce"] = "other-nonce" }},
+		{"subject", func(claims map[string]interface{}) { delete(claims, "sub") }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, issuer := newTestProvider(t)
+			issuer.Mutate = test.mutate
+
+			if _, err := login(provider, issuer, ""); err == nil {
+				t.Error("expected the id_token is refused")
+			}
+		})
+	}
+}
+
+func TestVerifyRefusesInvalidSignature(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	if _, err := provider.AuthCodeURL("state", "challenge", "nonce"); err != nil {
+		t.Fatal(err)
+	}
+
+	idToken, err := issuer.IdToken("nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.verify(idToken, "nonce", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.Claims["groups"] = []string{"admins"}
+	otherToken, _ := issuer.IdToken("nonce")
+	parts, otherParts := strings.Split(idToken, "."), strings.Split(otherToken, ".")
+	forged := parts[0] + "." + otherParts[1] + "." + parts[2]
+	if _, err := provider.verify(forged, "nonce", time.Now()); err == nil ||
+		!strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected the forged id_token is refused, got %v", err)
+	}
+
+	unsigned := parts[0] + "." + parts[1] + "."
+	if _, err := provider.verify(unsigned, "nonce", time.Now()); err == nil {
+		t.Error("expected the id_token without signature is refused")
+	}
+}
+
+func TestJwksRotation(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	if _, err := login(provider, issuer, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.RotateKey(true)
+	if _, err := login(provider, issuer, ""); err == nil {
+		t.Error("expected the new key isn't fetched before the refresh interval")
+	}
+
+	if issuer.JwksRequests() != 1 {
+		t.Errorf("expected 1 request of the JWKS, got %d", issuer.JwksRequests())
+	}
+
+	provider.keys.fetchedAt = time.Now().Add(-jwksRefreshInterval)
+	if _, err := login(provider, issuer, ""); err != nil {
+		t.Fatalf("expected the rotated key is fetched, got %v", err)
+	}
+
+	issuer.RotateKey(false)
+	provider.keys.fetchedAt = time.Now().Add(-jwksRefreshInterval)
+	for index := 0; index < 3; index++ {
+		if _, err := login(provider, issuer, ""); err == nil {
+			t.Error("expected the key not on the JWKS is refused")
+		}
+	}
+
+	if issuer.JwksRequests() != 3 {
+		t.Errorf("expected the unknown key fetches the JWKS once, got %d requests", issuer.JwksRequests())
+	}
+}
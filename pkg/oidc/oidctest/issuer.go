@@ -0,0 +1,260 @@
+// Package oidctest runs a local OpenID Connect issuer for the tests of the
+// OIDC login.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type signingKey struct {
+	kid       string
+	signer    crypto.Signer
+	published bool
+}
+
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+// Issuer signs the ID tokens with the key published on the JWKS and checks
+// the PKCE verifier of the code on the token endpoint.
+type Issuer struct {
+	URL      string
+	ClientId string
+	// Claims are added to the ID tokens, like sub, email and groups.
+	Claims map[string]interface{}
+	// Mutate changes the claims of the next ID tokens, to issue invalid tokens.
+	Mutate func(claims map[string]interface{})
+
+	server         *httptest.Server
+	mutex          sync.Mutex
+	keys           []*signingKey
+	authorizations map[string]authorization
+	jwksRequests   int
+}
+
+func NewIssuer(clientId string) *Issuer {
+	issuer := &Issuer{
+		ClientId:       clientId,
+		Claims:         map[string]interface{}{},
+		authorizations: map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	mux.HandleFunc("/token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+	issuer.URL = issuer.server.URL
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	issuer.keys = []*signingKey{{kid: "key-1", signer: key, published: true}}
+
+	return issuer
+}
+
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// RotateKey signs the next ID tokens with a new ES256 key, published on the
+// JWKS only when published is true.
+func (i *Issuer) RotateKey(published bool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.keys = append(i.keys, &signingKey{
+		kid:       fmt.Sprintf("key-%d", len(i.keys)+1),
+		signer:    key,
+		published: published,
+	})
+}
+
+// JwksRequests returns how many times the JWKS was fetched.
+func (i *Issuer) JwksRequests() int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.jwksRequests
+}
+
+// Authorize is the user login on the provider: it reads the authorization url
+// and returns the code and the state of the redirect to the client.
+func (i *Issuer) Authorize(authUrl string) (string, string, error) {
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		return "", "", err
+	}
+
+	query := parsed.Query()
+	if query.Get("client_id") != i.ClientId || query.Get("code_challenge_method") != "S256" {
+		return "", "", fmt.Errorf("the authorization url %s is invalid", authUrl)
+	}
+
+	code := randomString()
+	i.mutex.Lock()
+	i.authorizations[code] = authorization{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+	}
+	i.mutex.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 i.URL,
+		"authorization_endpoint": i.URL + "/authorize",
+		"token_endpoint":         i.URL + "/token",
+		"jwks_uri":               i.URL + "/jwks",
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.jwksRequests++
+
+	keys := []map[string]string{}
+	for _, key := range i.keys {
+		if !key.published {
+			continue
+		}
+
+		switch publicKey := key.signer.Public().(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kid": key.kid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kid": key.kid,
+				"kty": "EC",
+				"use": "sig",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, 32))),
+				"y":   base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+
+	writeJSON(w, map[string]interface{}{"keys": keys})
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != i.ClientId {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	i.mutex.Lock()
+	code := r.PostForm.Get("code")
+	login, ok := i.authorizations[code]
+	delete(i.authorizations, code)
+	i.mutex.Unlock()
+
+	hash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(hash[:]) != login.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	idToken, err := i.IdToken(login.nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+// IdToken returns an ID token signed with the last key.
+func (i *Issuer) IdToken(nonce string) (string, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   i.URL,
+		"aud":   i.ClientId,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	for name, value := range i.Claims {
+		claims[name] = value
+	}
+	if i.Mutate != nil {
+		i.Mutate(claims)
+	}
+
+	key := i.keys[len(i.keys)-1]
+	alg := "RS256"
+	if _, ok := key.signer.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": key.kid, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	content := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hashed := sha256.Sum256([]byte(content))
+
+	var signature []byte
+	switch signer := key.signer.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, hashed[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, signer, hashed[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return content + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func randomString() string {
+	data := make([]byte, 16)
+	rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
@@ -2,8 +2,11 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/hibiken/asynq"
 )
@@ -14,23 +17,48 @@ type IConsumer interface {
 
 type Handler func([]byte) error
 
+// ErrDeferred tells the consumer to try the task again later without counting
+// it as a failed attempt.
+var ErrDeferred = errors.New("task deferred")
+
+const deferDelay = 30 * time.Second
+
 type Consumer struct {
 	client    *asynq.Server
 	mux       *asynq.ServeMux
 	queueName string
 }
 
-func NewConsumer(queueName string, handler Handler) *Consumer {
+func NewConsumer(queueName string, handler Handler, extraQueues ...string) *Consumer {
 	redisAddr := os.Getenv("REDIS_URL")
+	concurrency, err := strconv.Atoi(os.Getenv("WORKER_CONCURRENCY"))
+	if err != nil || concurrency < 1 {
+		concurrency = 1
+	}
+
+	queues := map[string]int{
+		"critical": 6,
+		"default":  3,
+		"low":      1,
+	}
+	for _, extraQueue := range extraQueues {
+		queues[extraQueue] = 3
+	}
 
 	client := asynq.NewServer(
 		asynq.RedisClientOpt{Addr: redisAddr},
 		asynq.Config{
-			Concurrency: 1,
-			Queues: map[string]int{
-				"critical": 6,
-				"default":  3,
-				"low":      1,
+			Concurrency: concurrency,
+			Queues:      queues,
+			IsFailure: func(err error) bool {
+				return !errors.Is(err, ErrDeferred)
+			},
+			RetryDelayFunc: func(n int, err error, task *asynq.Task) time.Duration {
+				if errors.Is(err, ErrDeferred) {
+					return deferDelay
+				}
+
+				return asynq.DefaultRetryDelayFunc(n, err, task)
 			},
 		},
 	)
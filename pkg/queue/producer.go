@@ -9,6 +9,7 @@ import (
 
 type IProducer interface {
 	Publish(payload interface{})
+	PublishOnQueue(queue string, payload interface{})
 	Close()
 }
 
@@ -36,6 +37,15 @@ func (p *Producer) Publish(payload interface{}) {
 	)
 }
 
+func (p *Producer) PublishOnQueue(queue string, payload interface{}) {
+	payloadSendQeueue, _ := json.Marshal(payload)
+
+	p.client.Enqueue(
+		asynq.NewTask(p.queueName, payloadSendQeueue),
+		asynq.Queue(queue),
+	)
+}
+
 func (p *Producer) Close() {
 	p.client.Close()
 }
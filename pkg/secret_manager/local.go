@@ -0,0 +1,105 @@
+package secretmanager
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
+	"gorm.io/gorm"
+)
+
+type LocalSecret struct {
+	gorm.Model
+	Key   string `gorm:"uniqueIndex"`
+	Value string
+}
+
+// LocalSecretManager keeps the secrets encrypted with AES-GCM on one table of
+// the database, so no external secret manager is needed.
+type LocalSecretManager struct {
+	db         *gorm.DB
+	encryption encryption.IEncryption
+}
+
+func localMasterKey() ([]byte, error) {
+	masterKey := os.Getenv("LOCAL_SECRETS_KEY")
+	if keyFile := os.Getenv("LOCAL_SECRETS_KEY_FILE"); len(keyFile) > 0 {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		masterKey = string(content)
+	}
+
+	if len(masterKey) == 0 {
+		return nil, errors.New("LOCAL_SECRETS_KEY or LOCAL_SECRETS_KEY_FILE is required")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(masterKey))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != 32 {
+		return nil, errors.New("the local secrets key must have 32 bytes")
+	}
+
+	return key, nil
+}
+
+func NewLocal(db *gorm.DB) ISecretManager {
+	masterKey, err := localMasterKey()
+	if err != nil {
+		log.Fatalf("Failed to initialize local secret manager: %v", err)
+	}
+
+	localEncryption, err := encryption.NewWithKeys(
+		[]encryption.Key{{ID: "local", Value: masterKey}}, "local",
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize local secret manager: %v", err)
+	}
+
+	if err := db.AutoMigrate(&LocalSecret{}); err != nil {
+		log.Fatalf("Failed to create local secrets table: %v", err)
+	}
+
+	return &LocalSecretManager{
+		db:         db,
+		encryption: localEncryption,
+	}
+}
+
+func (l *LocalSecretManager) Add(key string, value string) error {
+	encrypted, err := l.encryption.Encrypt(value)
+	if err != nil {
+		return err
+	}
+
+	secret := LocalSecret{}
+	return l.db.Where(LocalSecret{Key: key}).
+		Assign(LocalSecret{Value: encrypted}).
+		FirstOrCreate(&secret).Error
+}
+
+func (l *LocalSecretManager) Get(key string) (string, error) {
+	secret := LocalSecret{}
+	err := l.db.First(&secret, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return l.encryption.Decrypt(secret.Value)
+}
+
+func (l *LocalSecretManager) Delete(key string) error {
+	return l.db.Unscoped().Where("key = ?", key).Delete(&LocalSecret{}).Error
+}
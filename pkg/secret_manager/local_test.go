@@ -0,0 +1,130 @@
+package secretmanager
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newLocalKey(t *testing.T) string {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func newTestLocal(t *testing.T) (ISecretManager, *gorm.DB) {
+	db, err := gorm.Open(
+		sqlite.Open(filepath.Join(t.TempDir(), "database")),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LOCAL_SECRETS_KEY", newLocalKey(t))
+	t.Setenv("LOCAL_SECRETS_KEY_FILE", "")
+	return NewLocal(db), db
+}
+
+func TestLocalSecretManager(t *testing.T) {
+	local, db := newTestLocal(t)
+
+	if err := local.Add("hash-deploy-key", "first value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := local.Add("hash-deploy-key", "second value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := local.Get("hash-deploy-key"); err != nil || value != "second value" {
+		t.Errorf("expected the value replaced, got %q %v", value, err)
+	}
+
+	var stored []LocalSecret
+	db.Find(&stored)
+	if len(stored) != 1 {
+		t.Fatalf("expected one register for the key, got %d", len(stored))
+	}
+
+	if strings.Contains(stored[0].Value, "second value") || !strings.HasPrefix(stored[0].Value, "enc:v1:local:") {
+		t.Errorf("expected the value stored encrypted, got %s", stored[0].Value)
+	}
+
+	if err := local.Delete("hash-deploy-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := local.Get("hash-deploy-key"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound after the delete, got %v", err)
+	}
+
+	if err := local.Add("hash-deploy-key", "third value"); err != nil {
+		t.Fatalf("expected the key added again after the delete, got %v", err)
+	}
+
+	if _, err := local.Get("missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestLocalSecretManagerOtherKey(t *testing.T) {
+	local, db := newTestLocal(t)
+	if err := local.Add("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LOCAL_SECRETS_KEY", newLocalKey(t))
+	if _, err := NewLocal(db).Get("key"); err == nil {
+		t.Error("expected the value refused by other LOCAL_SECRETS_KEY")
+	}
+}
+
+func TestLocalMasterKey(t *testing.T) {
+	valid := newLocalKey(t)
+	keyFile := filepath.Join(t.TempDir(), "local.key")
+	if err := os.WriteFile(keyFile, []byte(valid+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		keyFile string
+		valid   bool
+	}{
+		{"base64 key", valid, "", true},
+		{"key with spaces", " " + valid + "\n", "", true},
+		{"key file", "", keyFile, true},
+		{"key file preferred", "invalid", keyFile, true},
+		{"missing", "", "", false},
+		{"missing key file", valid, filepath.Join(t.TempDir(), "missing"), false},
+		{"not base64", "not-base64!", "", false},
+		{"16 bytes", base64.StdEncoding.EncodeToString(make([]byte, 16)), "", false},
+		{"64 bytes", base64.StdEncoding.EncodeToString(make([]byte, 64)), "", false},
+	}
+
+	for _, test := range tests {
+		t.Setenv("LOCAL_SECRETS_KEY", test.key)
+		t.Setenv("LOCAL_SECRETS_KEY_FILE", test.keyFile)
+		key, err := localMasterKey()
+		if (err == nil) != test.valid {
+			t.Errorf("%s: localMasterKey = %v, expected valid %v", test.name, err, test.valid)
+		}
+
+		if err == nil && len(key) != 32 {
+			t.Errorf("%s: expected 32 bytes, got %d", test.name, len(key))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+//go:build !nophase
+
+package secretmanager
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/phasehq/golang-sdk/phase"
+)
+
+// phaseNotFoundErrors are the messages of the Phase sdk when the key doesn't
+// exist, it doesn't have a typed error for that.
+var phaseNotFoundErrors = []string{"no secrets found in the response", "status code 404"}
+
+type PhaseSecretManager struct {
+	client *phase.Phase
+}
+
+func NewPhase(enableDebug bool) ISecretManager {
+	phaseTokenService := os.Getenv("PHASE_TOKEN_SERVICE")
+	host := os.Getenv("PHASE_HOST")
+	phaseClient := phase.Init(phaseTokenService, host, enableDebug)
+
+	if phaseClient == nil {
+		log.Fatal("Failed to initialize Phase client")
+	}
+
+	return &PhaseSecretManager{
+		client: phaseClient,
+	}
+}
+
+func (s *PhaseSecretManager) Add(key string, value string) error {
+	appName := os.Getenv("PHASE_PROJECT")
+	envName := os.Getenv("PHASE_ENV")
+
+	opts := phase.CreateSecretsOptions{
+		KeyValuePairs: []map[string]string{
+			{(key): value},
+		},
+		EnvName: envName,
+		AppName: appName,
+	}
+
+	err := s.client.Create(opts)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PhaseSecretManager) Get(key string) (string, error) {
+	appName := os.Getenv("PHASE_PROJECT")
+	envName := os.Getenv("PHASE_ENV")
+
+	opts := phase.GetSecretOptions{
+		KeyToFind: key,
+		EnvName:   envName,
+		AppName:   appName,
+	}
+
+	secret, err := s.client.Get(opts)
+	if err != nil {
+		for _, message := range phaseNotFoundErrors {
+			if strings.Contains(err.Error(), message) {
+				return "", fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+			}
+		}
+
+		return "", err
+	}
+
+	value, ok := (*secret)["value"].(string)
+	if !ok {
+		return "", fmt.Errorf("the Phase secret %s has no value", key)
+	}
+
+	return value, nil
+}
+
+func (s *PhaseSecretManager) Delete(key string) error {
+	appName := os.Getenv("PHASE_PROJECT")
+	envName := os.Getenv("PHASE_ENV")
+
+	opts := phase.DeleteSecretOptions{
+		KeyToDelete: key,
+		EnvName:     envName,
+		AppName:     appName,
+	}
+
+	return s.client.Delete(opts)
+}
@@ -0,0 +1,10 @@
+//go:build nophase
+
+package secretmanager
+
+import "log"
+
+func NewPhase(enableDebug bool) ISecretManager {
+	log.Fatal("The binary was built with the tag nophase, use SECRET_MANAGER=local or vault")
+	return nil
+}
@@ -0,0 +1,223 @@
+package secretmanager
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tiago123456789/own-githubaction/pkg/encryption"
+	"go.uber.org/zap"
+)
+
+var ErrUnavailable = errors.New("secret backend unavailable")
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+type getResult struct {
+	value string
+	err   error
+}
+
+// ResilientSecretManager wraps the secret manager with a short cache, a
+// timeout and a circuit breaker, so a slow or down backend fails fast with
+// ErrUnavailable. The cached values are encrypted with a key that only
+// exists on the memory of the process.
+type ResilientSecretManager struct {
+	backend          ISecretManager
+	logger           *zap.Logger
+	encryption       encryption.IEncryption
+	ttl              time.Duration
+	timeout          time.Duration
+	failureThreshold int
+	openDuration     time.Duration
+	mutex            sync.Mutex
+	cache            map[string]cachedSecret
+	failures         int
+	openUntil        time.Time
+	// probing is true while the one request allowed after the open duration
+	// hasn't finished.
+	probing bool
+}
+
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		log.Fatalf("%s must be a number of seconds", name)
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+func NewResilient(backend ISecretManager, logger *zap.Logger) ISecretManager {
+	cacheKey := make([]byte, 32)
+	if _, err := rand.Read(cacheKey); err != nil {
+		log.Fatalf("Failed to generate secret cache key: %v", err)
+	}
+
+	cacheEncryption, err := encryption.NewWithKeys(
+		[]encryption.Key{{ID: "cache", Value: cacheKey}}, "cache",
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize secret cache: %v", err)
+	}
+
+	return &ResilientSecretManager{
+		backend:          backend,
+		logger:           logger,
+		encryption:       cacheEncryption,
+		ttl:              durationFromEnv("SECRET_CACHE_TTL", time.Minute),
+		timeout:          durationFromEnv("SECRET_MANAGER_TIMEOUT", 10*time.Second),
+		failureThreshold: 5,
+		openDuration:     durationFromEnv("SECRET_MANAGER_OPEN_DURATION", 30*time.Second),
+		cache:            map[string]cachedSecret{},
+	}
+}
+
+func (r *ResilientSecretManager) Add(key string, value string) error {
+	r.forget(key)
+	return r.backend.Add(key, value)
+}
+
+func (r *ResilientSecretManager) Delete(key string) error {
+	r.forget(key)
+	return r.backend.Delete(key)
+}
+
+func (r *ResilientSecretManager) Get(key string) (string, error) {
+	if value, ok := r.fromCache(key); ok {
+		return value, nil
+	}
+
+	if !r.allowRequest() {
+		return "", fmt.Errorf("%w: the circuit breaker is open", ErrUnavailable)
+	}
+
+	result := make(chan getResult, 1)
+	go func() {
+		value, err := r.backend.Get(key)
+		result <- getResult{value: value, err: err}
+	}()
+
+	select {
+	case <-time.After(r.timeout):
+		r.recordFailure()
+		return "", fmt.Errorf("%w: timeout after %s", ErrUnavailable, r.timeout)
+	case got := <-result:
+		if got.err != nil {
+			if errors.Is(got.err, ErrSecretNotFound) {
+				r.recordSuccess()
+				return "", got.err
+			}
+
+			r.recordFailure()
+			return "", fmt.Errorf("%w: %v", ErrUnavailable, got.err)
+		}
+
+		r.recordSuccess()
+		r.toCache(key, got.value)
+		return got.value, nil
+	}
+}
+
+func (r *ResilientSecretManager) fromCache(key string) (string, bool) {
+	r.mutex.Lock()
+	cached, ok := r.cache[key]
+	r.mutex.Unlock()
+
+	if !ok || time.Now().After(cached.expiresAt) {
+		return "", false
+	}
+
+	value, err := r.encryption.Decrypt(cached.value)
+	if err != nil {
+		return "", false
+	}
+
+	return value, true
+}
+
+func (r *ResilientSecretManager) toCache(key string, value string) {
+	if r.ttl == 0 {
+		return
+	}
+
+	encrypted, err := r.encryption.Encrypt(value)
+	if err != nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for cachedKey, cached := range r.cache {
+		if now.After(cached.expiresAt) {
+			delete(r.cache, cachedKey)
+		}
+	}
+	r.cache[key] = cachedSecret{value: encrypted, expiresAt: now.Add(r.ttl)}
+}
+
+func (r *ResilientSecretManager) forget(key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.cache, key)
+}
+
+// allowRequest is false while the circuit is open. After the open duration
+// one request is allowed, the others are refused until it finishes, and the
+// circuit opens again if it fails.
+func (r *ResilientSecretManager) allowRequest() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.openUntil.IsZero() {
+		return true
+	}
+
+	if time.Now().Before(r.openUntil) || r.probing {
+		return false
+	}
+
+	r.probing = true
+	return true
+}
+
+func (r *ResilientSecretManager) recordFailure() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.failures++
+	if r.probing || r.failures >= r.failureThreshold {
+		r.probing = false
+		r.openUntil = time.Now().Add(r.openDuration)
+		r.logger.Warn(fmt.Sprintf(
+			"The secret backend failed %d times, the circuit breaker is open for %s", r.failures, r.openDuration,
+		))
+	}
+}
+
+func (r *ResilientSecretManager) recordSuccess() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.failures = 0
+	r.openUntil = time.Time{}
+	r.probing = false
+}
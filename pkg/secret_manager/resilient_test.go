@@ -0,0 +1,148 @@
+package secretmanager
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type flakyBackend struct {
+	mutex   sync.Mutex
+	err     error
+	calls   int
+	release chan struct{}
+}
+
+func (f *flakyBackend) Add(key string, value string) error {
+	return nil
+}
+
+func (f *flakyBackend) Get(key string) (string, error) {
+	f.mutex.Lock()
+	f.calls++
+	release := f.release
+	err := f.err
+	f.mutex.Unlock()
+
+	if release != nil {
+		<-release
+	}
+
+	return "value", err
+}
+
+func (f *flakyBackend) Delete(key string) error {
+	return nil
+}
+
+func (f *flakyBackend) Calls() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.calls
+}
+
+func newTestResilient(t *testing.T, backend ISecretManager) (*ResilientSecretManager, *observer.ObservedLogs) {
+	t.Setenv("SECRET_CACHE_TTL", "0")
+	t.Setenv("SECRET_MANAGER_TIMEOUT", "5")
+	t.Setenv("SECRET_MANAGER_OPEN_DURATION", "30")
+	core, logs := observer.New(zap.WarnLevel)
+	return NewResilient(backend, zap.New(core)).(*ResilientSecretManager), logs
+}
+
+func TestResilientOpensAfterFailures(t *testing.T) {
+	backend := &flakyBackend{err: errors.New("connection refused")}
+	resilient, logs := newTestResilient(t, backend)
+
+	for index := 0; index < resilient.failureThreshold; index++ {
+		if _, err := resilient.Get("key"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+	}
+
+	if _, err := resilient.Get("key"); !errors.Is(err, ErrUnavailable) || backend.Calls() != resilient.failureThreshold {
+		t.Errorf("expected the open circuit refuses without calling the backend, got %v", err)
+	}
+
+	if logs.Len() != 1 {
+		t.Errorf("expected the open circuit logged once, got %v", logs.All())
+	}
+}
+
+func TestResilientNotFoundIsNotFailure(t *testing.T) {
+	backend := &flakyBackend{err: ErrSecretNotFound}
+	resilient, _ := newTestResilient(t, backend)
+
+	for index := 0; index < resilient.failureThreshold+1; index++ {
+		if _, err := resilient.Get("key"); !errors.Is(err, ErrSecretNotFound) {
+			t.Fatalf("expected ErrSecretNotFound, got %v", err)
+		}
+	}
+}
+
+func TestResilientHalfOpenAllowsOneRequest(t *testing.T) {
+	backend := &flakyBackend{err: errors.New("connection refused")}
+	resilient, _ := newTestResilient(t, backend)
+	for index := 0; index < resilient.failureThreshold; index++ {
+		resilient.Get("key")
+	}
+
+	backend.mutex.Lock()
+	backend.err = nil
+	backend.release = make(chan struct{})
+	backend.mutex.Unlock()
+	resilient.mutex.Lock()
+	resilient.openUntil = time.Now().Add(-time.Second)
+	resilient.mutex.Unlock()
+
+	probe := make(chan error, 1)
+	go func() {
+		_, err := resilient.Get("key")
+		probe <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for backend.Calls() == resilient.failureThreshold && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for index := 0; index < 3; index++ {
+		if _, err := resilient.Get("key"); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("expected the requests during the probe refused, got %v", err)
+		}
+	}
+
+	close(backend.release)
+	if err := <-probe; err != nil {
+		t.Fatalf("expected the probe succeeds, got %v", err)
+	}
+
+	if backend.Calls() != resilient.failureThreshold+1 {
+		t.Errorf("expected one probe on the backend, got %d calls", backend.Calls()-resilient.failureThreshold)
+	}
+
+	if _, err := resilient.Get("key"); err != nil {
+		t.Errorf("expected the circuit closed after the probe, got %v", err)
+	}
+}
+
+func TestResilientFailedProbeOpensAgain(t *testing.T) {
+	backend := &flakyBackend{err: errors.New("connection refused")}
+	resilient, _ := newTestResilient(t, backend)
+	for index := 0; index < resilient.failureThreshold; index++ {
+		resilient.Get("key")
+	}
+
+	resilient.mutex.Lock()
+	resilient.openUntil = time.Now().Add(-time.Second)
+	resilient.mutex.Unlock()
+
+	resilient.Get("key")
+	if _, err := resilient.Get("key"); !errors.Is(err, ErrUnavailable) ||
+		backend.Calls() != resilient.failureThreshold+1 {
+		t.Errorf("expected the failed probe opens the circuit again, got %v", err)
+	}
+}
@@ -4,68 +4,26 @@ import (
 	"log"
 	"os"
 
-	"github.com/phasehq/golang-sdk/phase"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type ISecretManager interface {
 	Add(key string, value string) error
 	Get(key string) (string, error)
+	Delete(key string) error
 }
 
-type SecretManager struct {
-	client *phase.Phase
-}
-
-func New(enableDebug bool) *SecretManager {
-	phaseTokenService := os.Getenv("PHASE_TOKEN_SERVICE")
-	host := os.Getenv("PHASE_HOST")
-	phaseClient := phase.Init(phaseTokenService, host, enableDebug)
-
-	if phaseClient == nil {
-		log.Fatal("Failed to initialize Phase client")
-	}
-
-	return &SecretManager{
-		client: phaseClient,
-	}
-}
-
-func (s *SecretManager) Add(key string, value string) error {
-	appName := os.Getenv("PHASE_PROJECT")
-	envName := os.Getenv("PHASE_ENV")
-
-	opts := phase.CreateSecretsOptions{
-		KeyValuePairs: []map[string]string{
-			{(key): value},
-		},
-		EnvName: envName,
-		AppName: appName,
-	}
-
-	err := s.client.Create(opts)
-
-	if err != nil {
-		return err
+func New(enableDebug bool, db *gorm.DB, logger *zap.Logger) ISecretManager {
+	switch os.Getenv("SECRET_MANAGER") {
+	case "", "phase":
+		return NewResilient(NewPhase(enableDebug), logger)
+	case "local":
+		return NewResilient(NewLocal(db), logger)
+	case "vault":
+		return NewResilient(NewVault(logger), logger)
 	}
 
+	log.Fatalf("Invalid SECRET_MANAGER %s, the options are phase, local and vault", os.Getenv("SECRET_MANAGER"))
 	return nil
 }
-
-func (s *SecretManager) Get(key string) (string, error) {
-	appName := os.Getenv("PHASE_PROJECT")
-	envName := os.Getenv("PHASE_ENV")
-
-	opts := phase.GetSecretOptions{
-		KeyToFind: key,
-		EnvName:   envName,
-		AppName:   appName,
-	}
-
-	secret, err := s.client.Get(opts)
-	if err != nil {
-		return "", err
-	}
-
-	value := (*secret)["value"].(string)
-	return value, nil
-}
@@ -0,0 +1,285 @@
+package secretmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// VaultSecretManager stores each secret on one path of a Vault KV v2 mount,
+// authenticating with token or AppRole and renewing the token lease.
+type VaultSecretManager struct {
+	address      string
+	namespace    string
+	mount        string
+	pathTemplate string
+	roleId       string
+	secretId     string
+	httpClient   *http.Client
+	logger       *zap.Logger
+	mutex        sync.RWMutex
+	token        string
+	// tokenErr is why the token stopped being renewed, returned by the
+	// requests after tokenExpiresAt.
+	tokenErr       error
+	tokenExpiresAt time.Time
+}
+
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+func NewVault(logger *zap.Logger) ISecretManager {
+	mount := os.Getenv("VAULT_MOUNT")
+	if len(mount) == 0 {
+		mount = "secret"
+	}
+
+	pathTemplate := os.Getenv("VAULT_PATH_TEMPLATE")
+	if len(pathTemplate) == 0 {
+		pathTemplate = "ci/triggers/{key}"
+	}
+
+	vault := &VaultSecretManager{
+		address:      strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/"),
+		namespace:    os.Getenv("VAULT_NAMESPACE"),
+		mount:        strings.Trim(mount, "/"),
+		pathTemplate: strings.Trim(pathTemplate, "/"),
+		roleId:       os.Getenv("VAULT_ROLE_ID"),
+		secretId:     os.Getenv("VAULT_SECRET_ID"),
+		token:        os.Getenv("VAULT_TOKEN"),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}
+
+	if len(vault.address) == 0 {
+		log.Fatal("VAULT_ADDR is required when SECRET_MANAGER is vault")
+	}
+
+	auth, err := vault.authenticate()
+	if err != nil {
+		log.Fatalf("Failed to authenticate on Vault: %v", err)
+	}
+
+	go vault.keepTokenAlive(auth)
+
+	return vault
+}
+
+func (v *VaultSecretManager) authenticate() (vaultAuth, error) {
+	if len(v.roleId) > 0 {
+		return v.loginAppRole()
+	}
+
+	if len(v.token) == 0 {
+		return vaultAuth{}, errors.New("VAULT_TOKEN or VAULT_ROLE_ID and VAULT_SECRET_ID are required")
+	}
+
+	response := struct {
+		Data struct {
+			TTL       int  `json:"ttl"`
+			Renewable bool `json:"renewable"`
+		} `json:"data"`
+	}{}
+	err := v.request(http.MethodGet, "auth/token/lookup-self", nil, &response)
+	if err != nil {
+		return vaultAuth{}, err
+	}
+
+	return vaultAuth{
+		ClientToken:   v.currentToken(),
+		LeaseDuration: response.Data.TTL,
+		Renewable:     response.Data.Renewable,
+	}, nil
+}
+
+func (v *VaultSecretManager) loginAppRole() (vaultAuth, error) {
+	response := struct {
+		Auth vaultAuth `json:"auth"`
+	}{}
+	err := v.request(http.MethodPost, "auth/approle/login", map[string]string{
+		"role_id":   v.roleId,
+		"secret_id": v.secretId,
+	}, &response)
+	if err != nil {
+		return vaultAuth{}, err
+	}
+
+	v.mutex.Lock()
+	v.token = response.Auth.ClientToken
+	v.mutex.Unlock()
+
+	return response.Auth, nil
+}
+
+// keepTokenAlive renews the token when 2/3 of the lease passed. When the
+// token can't be renewed anymore the AppRole login runs again, a VAULT_TOKEN
+// can't login again so the requests fail after it expires.
+func (v *VaultSecretManager) keepTokenAlive(auth vaultAuth) {
+	for auth.LeaseDuration > 0 {
+		time.Sleep(time.Duration(auth.LeaseDuration) * time.Second * 2 / 3)
+
+		renewed := vaultAuth{}
+		var err error
+		if auth.Renewable {
+			response := struct {
+				Auth vaultAuth `json:"auth"`
+			}{}
+			err = v.request(http.MethodPost, "auth/token/renew-self", map[string]string{}, &response)
+			renewed = response.Auth
+		}
+
+		if !auth.Renewable || err != nil || renewed.LeaseDuration < auth.LeaseDuration/2 {
+			if len(v.roleId) == 0 {
+				v.stopRenewing(auth, renewed, err)
+				return
+			}
+
+			renewed, err = v.loginAppRole()
+			if err != nil {
+				v.logger.Error(fmt.Sprintf("Failed to login on Vault again: %v", err))
+				time.Sleep(30 * time.Second)
+				continue
+			}
+		}
+
+		auth = renewed
+	}
+}
+
+func (v *VaultSecretManager) stopRenewing(auth vaultAuth, renewed vaultAuth, err error) {
+	expiresAt := time.Now().Add(time.Duration(auth.LeaseDuration) * time.Second / 3)
+	reason := "the token isn't renewable"
+	if err != nil {
+		reason = err.Error()
+	} else if auth.Renewable {
+		expiresAt = time.Now().Add(time.Duration(renewed.LeaseDuration) * time.Second)
+		reason = "the token reached the max TTL"
+	}
+
+	v.mutex.Lock()
+	v.tokenErr = fmt.Errorf("the Vault token expired at %s and wasn't renewed: %s", expiresAt.Format(time.RFC3339), reason)
+	v.tokenExpiresAt = expiresAt
+	v.mutex.Unlock()
+
+	v.logger.Error(fmt.Sprintf(
+		"Failed to renew the Vault token, it expires at %s: %s. Use VAULT_ROLE_ID and VAULT_SECRET_ID to login again automatically",
+		expiresAt.Format(time.RFC3339), reason,
+	))
+}
+
+// tokenError returns why the token stopped being renewed once it expired.
+func (v *VaultSecretManager) tokenError() error {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	if v.tokenErr != nil && time.Now().After(v.tokenExpiresAt) {
+		return v.tokenErr
+	}
+
+	return nil
+}
+
+func (v *VaultSecretManager) currentToken() string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	return v.token
+}
+
+// secretPath returns the path of the key on the data or metadata api of the
+// KV v2 mount.
+func (v *VaultSecretManager) secretPath(api string, key string) string {
+	path := strings.ReplaceAll(v.pathTemplate, "{key}", key)
+	path = strings.ReplaceAll(path, "{hash}", key)
+	return fmt.Sprintf("%s/%s/%s", v.mount, api, path)
+}
+
+func (v *VaultSecretManager) request(method string, path string, body interface{}, result interface{}) error {
+	if err := v.tokenError(); err != nil {
+		return err
+	}
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+
+	request, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s", v.address, path), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if token := v.currentToken(); len(token) > 0 {
+		request.Header.Set("X-Vault-Token", token)
+	}
+	if len(v.namespace) > 0 {
+		request.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	response, err := v.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w on Vault path %s", ErrSecretNotFound, path)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("vault returned status %d for %s %s", response.StatusCode, method, path)
+	}
+
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (v *VaultSecretManager) Add(key string, value string) error {
+	return v.request(http.MethodPost, v.secretPath("data", key), map[string]interface{}{
+		"data": map[string]string{
+			"value": value,
+		},
+	}, nil)
+}
+
+func (v *VaultSecretManager) Get(key string) (string, error) {
+	response := struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}{}
+	err := v.request(http.MethodGet, v.secretPath("data", key), nil, &response)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := response.Data.Data["value"]
+	if !ok {
+		return "", fmt.Errorf("the Vault secret %s has no value", key)
+	}
+
+	return value, nil
+}
+
+// Delete removes the metadata, so every version of the secret is destroyed.
+func (v *VaultSecretManager) Delete(key string) error {
+	return v.request(http.MethodDelete, v.secretPath("metadata", key), nil, nil)
+}
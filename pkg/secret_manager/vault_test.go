@@ -0,0 +1,93 @@
+package secretmanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestVaultDevServer runs against a Vault dev server, started with
+// docker-compose up -d vault and the envs VAULT_ADDR=http://localhost:8200
+// and VAULT_TOKEN=root.
+func TestVaultDevServer(t *testing.T) {
+	if len(os.Getenv("VAULT_ADDR")) == 0 || len(os.Getenv("VAULT_TOKEN")) == 0 {
+		t.Skip("VAULT_ADDR and VAULT_TOKEN of a Vault dev server are required")
+	}
+
+	t.Setenv("VAULT_MOUNT", "secret")
+	t.Setenv("VAULT_PATH_TEMPLATE", "ci-test/{key}")
+	t.Setenv("VAULT_ROLE_ID", "")
+	vault := NewVault(zap.NewNop())
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+
+	if _, err := vault.Get(key); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected the missing secret not found, got %v", err)
+	}
+
+	for _, value := range []string{"first value", "second value"} {
+		if err := vault.Add(key, value); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := vault.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != value {
+			t.Errorf("expected %q, got %q", value, got)
+		}
+	}
+
+	if err := vault.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := vault.Get(key); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected the deleted secret not found, got %v", err)
+	}
+}
+
+func TestVaultTokenNotRenewable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			fmt.Fprint(w, `{"data":{"ttl":1,"renewable":false}}`)
+		default:
+			fmt.Fprint(w, `{"data":{"data":{"value":"s3cr3t"}}}`)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "short-lived")
+	t.Setenv("VAULT_ROLE_ID", "")
+	core, logs := observer.New(zap.ErrorLevel)
+	vault := NewVault(zap.New(core))
+
+	if value, err := vault.Get("key"); err != nil || value != "s3cr3t" {
+		t.Fatalf("expected the secret before the token expires, got %q and %v", value, err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for logs.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if logs.Len() != 1 || !strings.Contains(logs.All()[0].Message, "isn't renewable") {
+		t.Fatalf("expected the renewal failure logged, got %v", logs.All())
+	}
+
+	time.Sleep(time.Second)
+	if _, err := vault.Get("key"); err == nil || !strings.Contains(err.Error(), "wasn't renewed") {
+		t.Errorf("expected the requests fail after the token expired, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package sshkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const keyType = "ecdsa-sha2-nistp256"
+
+// Generate creates one ECDSA P-256 key pair, returning the private key in PEM
+// format and the public key in the authorized_keys format used by Github.
+func Generate(comment string) (string, string, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	privatePem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	blob := []byte{}
+	blob = appendString(blob, []byte(keyType))
+	blob = appendString(blob, []byte("nistp256"))
+	blob = appendString(blob, elliptic.Marshal(elliptic.P256(), privateKey.X, privateKey.Y))
+
+	publicKey := fmt.Sprintf("%s %s %s", keyType, base64.StdEncoding.EncodeToString(blob), comment)
+	return string(privatePem), strings.TrimSpace(publicKey), nil
+}
+
+func appendString(buffer []byte, value []byte) []byte {
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(value)))
+	return append(append(buffer, length...), value...)
+}
+
+func Validate(privateKey string) error {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(privateKey)))
+	if block == nil || !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		return errors.New("The field deployKey must be a private key in PEM or OpenSSH format")
+	}
+
+	if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") || strings.Contains(block.Type, "ENCRYPTED") {
+		return errors.New("The field deployKey can't be protected by passphrase")
+	}
+
+	return nil
+}
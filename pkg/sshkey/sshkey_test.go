@@ -0,0 +1,78 @@
+package sshkey
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	privateKey, publicKey, err := Generate("trigger-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Validate(privateKey); err != nil {
+		t.Errorf("expected the generated private key valid, got %v", err)
+	}
+
+	fields := strings.Fields(publicKey)
+	if len(fields) != 3 || fields[0] != keyType || fields[2] != "trigger-1" {
+		t.Fatalf("unexpected public key %s", publicKey)
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	length := binary.BigEndian.Uint32(blob[:4])
+	if !bytes.Equal(blob[4:4+length], []byte(keyType)) {
+		t.Errorf("expected the public key blob starting with %s", keyType)
+	}
+
+	otherKey, _, err := Generate("trigger-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otherKey == privateKey {
+		t.Error("expected a new key on each call")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	privateKey, _, err := Generate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted := string(pem.EncodeToMemory(&pem.Block{
+		Type:    "RSA PRIVATE KEY",
+		Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-128-CBC,00"},
+		Bytes:   []byte("key"),
+	}))
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"generated", privateKey, true},
+		{"with spaces", "\n  " + privateKey + "  \n", true},
+		{"openssh", string(pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: []byte("key")})), true},
+		{"empty", "", false},
+		{"not pem", "ssh-ed25519 AAAA", false},
+		{"public key", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")})), false},
+		{"encrypted pkcs8", string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("key")})), false},
+		{"encrypted legacy", encrypted, false},
+	}
+
+	for _, test := range tests {
+		if err := Validate(test.key); (err == nil) != test.valid {
+			t.Errorf("%s: Validate = %v, expected valid %v", test.name, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+package workflow
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Context struct {
+	Github    map[string]interface{}
+	Env       map[string]string
+	Secrets   map[string]string
+	Vars      map[string]string
+	Runner    map[string]interface{}
+	Needs     map[string]interface{}
+	JobStatus string
+}
+
+func (c Context) lookup(name string) (interface{}, bool) {
+	switch name {
+	case "github":
+		return c.Github, true
+	case "env":
+		return stringsToValues(c.Env), true
+	case "secrets":
+		return stringsToValues(c.Secrets), true
+	case "vars":
+		return stringsToValues(c.Vars), true
+	case "runner":
+		return c.Runner, true
+	case "needs":
+		return c.Needs, true
+	}
+
+	return nil, false
+}
+
+func stringsToValues(values map[string]string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range values {
+		result[key] = value
+	}
+
+	return result
+}
+
+var expressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+
+// Interpolate replaces every ${{ expression }} of the value by its result.
+func Interpolate(value string, ctx Context) (string, error) {
+	var evaluationErr error
+	result := expressionPattern.ReplaceAllStringFunc(value, func(match string) string {
+		expression := expressionPattern.FindStringSubmatch(match)[1]
+		evaluated, err := Evaluate(expression, ctx)
+		if err != nil {
+			evaluationErr = err
+			return match
+		}
+
+		return toString(evaluated)
+	})
+
+	return result, evaluationErr
+}
+
+// EvaluateCondition evaluates an if field. An empty condition means success()
+// and a condition without a status function only runs when the job didn't fail.
+func EvaluateCondition(condition string, ctx Context) (bool, error) {
+	condition = strings.TrimSpace(condition)
+	if strings.HasPrefix(condition, "${{") && strings.HasSuffix(condition, "}}") {
+		condition = strings.TrimSpace(condition[3 : len(condition)-2])
+	}
+
+	if len(condition) == 0 {
+		condition = "success()"
+	}
+
+	hasStatusFunction := false
+	for _, function := range []string{"success(", "failure(", "always(", "cancelled("} {
+		if strings.Contains(condition, function) {
+			hasStatusFunction = true
+		}
+	}
+
+	if !hasStatusFunction {
+		condition = fmt.Sprintf("success() && (%s)", condition)
+	}
+
+	value, err := Evaluate(condition, ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return isTruthy(value), nil
+}
+
+func Evaluate(expression string, ctx Context) (interface{}, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &parser{tokens: tokens, ctx: ctx}
+	value, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected %q in expression %q", parser.tokens[parser.position].value, expression)
+	}
+
+	return value, nil
+}
+
+type tokenKind int
+
+const (
+	tokenIdentifier tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ".", ","}
+
+func tokenize(expression string) ([]token, error) {
+	tokens := []token{}
+	for index := 0; index < len(expression); {
+		char := expression[index]
+		switch {
+		case char == ' ' || char == '\t' || char == '\n':
+			index++
+		case char == '\'':
+			value := ""
+			index++
+			for {
+				if index >= len(expression) {
+					return nil, fmt.Errorf("unterminated string in expression %q", expression)
+				}
+
+				if expression[index] == '\'' {
+					if index+1 < len(expression) && expression[index+1] == '\'' {
+						value += "'"
+						index += 2
+						continue
+					}
+
+					index++
+					break
+				}
+
+				value += string(expression[index])
+				index++
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value})
+		case char >= '0' && char <= '9' || char == '-':
+			start := index
+			index++
+			for index < len(expression) && strings.ContainsRune("0123456789.xabcdefABCDEF", rune(expression[index])) {
+				index++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: expression[start:index]})
+		case char == '_' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z':
+			start := index
+			for index < len(expression) && (expression[index] == '_' || expression[index] == '-' ||
+				expression[index] >= 'a' && expression[index] <= 'z' ||
+				expression[index] >= 'A' && expression[index] <= 'Z' ||
+				expression[index] >= '0' && expression[index] <= '9') {
+				index++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, value: expression[start:index]})
+		default:
+			matched := false
+			for _, operator := range operators {
+				if strings.HasPrefix(expression[index:], operator) {
+					tokens = append(tokens, token{kind: tokenOperator, value: operator})
+					index += len(operator)
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q in expression %q", char, expression)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens   []token
+	position int
+	ctx      Context
+}
+
+func (p *parser) peek(operator string) bool {
+	return p.position < len(p.tokens) &&
+		p.tokens[p.position].kind == tokenOperator &&
+		p.tokens[p.position].value == operator
+}
+
+func (p *parser) expect(operator string) error {
+	if !p.peek(operator) {
+		return fmt.Errorf("expected %q in expression", operator)
+	}
+
+	p.position++
+	return nil
+}
+
+func (p *parser) parseOr() (interface{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek("||") {
+		p.position++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		if !isTruthy(left) {
+			left = right
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (interface{}, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek("&&") {
+		p.position++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+
+		if isTruthy(left) {
+			left = right
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseComparison() (interface{}, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.peek(operator) {
+			continue
+		}
+
+		p.position++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return compare(operator, left, right), nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (interface{}, error) {
+	if p.peek("!") {
+		p.position++
+		value, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return !isTruthy(value), nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (interface{}, error) {
+	if p.position >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	current := p.tokens[p.position]
+	p.position++
+
+	switch current.kind {
+	case tokenString:
+		return current.value, nil
+	case tokenNumber:
+		return toNumber(current.value), nil
+	case tokenOperator:
+		if current.value != "(" {
+			return nil, fmt.Errorf("unexpected %q in expression", current.value)
+		}
+
+		value, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		return value, p.expect(")")
+	}
+
+	switch current.value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if p.peek("(") {
+		return p.parseCall(current.value)
+	}
+
+	value, ok := p.ctx.lookup(current.value)
+	if !ok {
+		return nil, fmt.Errorf("unknown context %q in expression", current.value)
+	}
+
+	for {
+		var property string
+		if p.peek(".") {
+			p.position++
+			if p.position >= len(p.tokens) || p.tokens[p.position].kind != tokenIdentifier {
+				return nil, fmt.Errorf("expected property name in expression")
+			}
+
+			property = p.tokens[p.position].value
+			p.position++
+		} else if p.peek("[") {
+			p.position++
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+
+			property = toString(index)
+		} else {
+			return value, nil
+		}
+
+		object, _ := value.(map[string]interface{})
+		value = nil
+		for key, propertyValue := range object {
+			if strings.EqualFold(key, property) {
+				value = propertyValue
+			}
+		}
+	}
+}
+
+func (p *parser) parseCall(name string) (interface{}, error) {
+	p.position++
+	arguments := []interface{}{}
+	for !p.peek(")") {
+		if len(arguments) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		argument, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		arguments = append(arguments, argument)
+	}
+	p.position++
+
+	switch strings.ToLower(name) {
+	case "success":
+		return p.ctx.JobStatus == "success", nil
+	case "failure":
+		return p.ctx.JobStatus == "failure", nil
+	case "cancelled":
+		return p.ctx.JobStatus == "cancelled", nil
+	case "always":
+		return true, nil
+	case "contains", "startswith", "endswith":
+		if len(arguments) != 2 {
+			return nil, fmt.Errorf("%s expects 2 arguments", name)
+		}
+
+		search := strings.ToLower(toString(arguments[0]))
+		item := strings.ToLower(toString(arguments[1]))
+		switch strings.ToLower(name) {
+		case "contains":
+			return strings.Contains(search, item), nil
+		case "startswith":
+			return strings.HasPrefix(search, item), nil
+		default:
+			return strings.HasSuffix(search, item), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown function %q in expression", name)
+}
+
+func compare(operator string, left interface{}, right interface{}) bool {
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		leftString = strings.ToLower(leftString)
+		rightString = strings.ToLower(rightString)
+		switch operator {
+		case "==":
+			return leftString == rightString
+		case "!=":
+			return leftString != rightString
+		case "<":
+			return leftString < rightString
+		case "<=":
+			return leftString <= rightString
+		case ">":
+			return leftString > rightString
+		default:
+			return leftString >= rightString
+		}
+	}
+
+	leftNumber := toNumber(left)
+	rightNumber := toNumber(right)
+	switch operator {
+	case "==":
+		return leftNumber == rightNumber
+	case "!=":
+		return leftNumber != rightNumber
+	case "<":
+		return leftNumber < rightNumber
+	case "<=":
+		return leftNumber <= rightNumber
+	case ">":
+		return leftNumber > rightNumber
+	default:
+		return leftNumber >= rightNumber
+	}
+}
+
+func isTruthy(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case float64:
+		return typed != 0 && !math.IsNaN(typed)
+	case string:
+		return len(typed) > 0
+	}
+
+	return true
+}
+
+func toNumber(value interface{}) float64 {
+	switch typed := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if typed {
+			return 1
+		}
+		return 0
+	case float64:
+		return typed
+	case string:
+		typed = strings.TrimSpace(typed)
+		if len(typed) == 0 {
+			return 0
+		}
+
+		if strings.HasPrefix(typed, "0x") {
+			number, err := strconv.ParseInt(typed[2:], 16, 64)
+			if err == nil {
+				return float64(number)
+			}
+		}
+
+		number, err := strconv.ParseFloat(typed, 64)
+		if err == nil {
+			return number
+		}
+	}
+
+	return math.NaN()
+}
+
+func toString(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case bool:
+		return strconv.FormatBool(typed)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	}
+
+	return ""
+}
@@ -0,0 +1,126 @@
+package workflow
+
+import (
+	"testing"
+)
+
+func testContext(jobStatus string) Context {
+	return Context{
+		Github: map[string]interface{}{
+			"ref":        "refs/heads/main",
+			"event_name": "push",
+			"event": map[string]interface{}{
+				"pull_request": map[string]interface{}{"number": float64(42)},
+			},
+		},
+		Env:       map[string]string{"STAGE": "production"},
+		Secrets:   map[string]string{"TOKEN": "s3cr3t"},
+		Vars:      map[string]string{"REPLICAS": "3"},
+		Needs:     map[string]interface{}{"build": map[string]interface{}{"result": "success"}},
+		JobStatus: jobStatus,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   interface{}
+	}{
+		{"github.ref == 'refs/heads/main'", true},
+		{"github.event_name != 'push'", false},
+		{"github.EVENT_NAME == 'PUSH'", true},
+		{"github['event_name']", "push"},
+		{"github.event.pull_request.number == 42", true},
+		{"github.event.unknown.number", nil},
+		{"vars.REPLICAS > 2 && vars.REPLICAS <= 3", true},
+		{"vars.MISSING || 'default'", "default"},
+		{"env.STAGE && 'deploy'", "deploy"},
+		{"!secrets.TOKEN", false},
+		{"!(1 == 2)", true},
+		{"0x10 == 16", true},
+		{"'it''s' == 'IT''S'", true},
+		{"null == 0", true},
+		{"'abc' == 1", false},
+		{"contains(github.ref, 'MAIN')", true},
+		{"startsWith(github.ref, 'refs/tags/')", false},
+		{"endsWith(github.ref, '/main')", true},
+		{"needs.build.result == 'success'", true},
+	}
+
+	for _, test := range tests {
+		value, err := Evaluate(test.expression, testContext("success"))
+		if err != nil {
+			t.Errorf("%s: %v", test.expression, err)
+			continue
+		}
+
+		if value != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.expression, test.expected, value)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	for _, expression := range []string{
+		"unknown.value",
+		"'unterminated",
+		"github.ref ==",
+		"format('{0}', 1)",
+		"contains(github.ref)",
+		"(1 == 1",
+		"1 == 1 )",
+		"github.ref # comment",
+		"github.",
+	} {
+		if _, err := Evaluate(expression, testContext("success")); err == nil {
+			t.Errorf("expected %q to fail", expression)
+		}
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	tests := []struct {
+		condition string
+		jobStatus string
+		expected  bool
+	}{
+		{"", "success", true},
+		{"", "failure", false},
+		{"github.ref == 'refs/heads/main'", "success", true},
+		{"${{ github.ref == 'refs/heads/main' }}", "success", true},
+		{"github.ref == 'refs/heads/main'", "failure", false},
+		{"failure()", "failure", true},
+		{"failure()", "success", false},
+		{"always() && github.event_name == 'push'", "failure", true},
+		{"cancelled()", "cancelled", true},
+		{"success() || github.event_name == 'push'", "failure", true},
+	}
+
+	for _, test := range tests {
+		result, err := EvaluateCondition(test.condition, testContext(test.jobStatus))
+		if err != nil {
+			t.Errorf("%q: %v", test.condition, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("%q with status %s: expected %v, got %v", test.condition, test.jobStatus, test.expected, result)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	value, err := Interpolate("deploy ${{ env.STAGE }} with ${{vars.REPLICAS}} replicas", testContext("success"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "deploy production with 3 replicas" {
+		t.Errorf("unexpected value %q", value)
+	}
+
+	value, err = Interpolate("token ${{ unknown.TOKEN }}", testContext("success"))
+	if err == nil || value != "token ${{ unknown.TOKEN }}" {
+		t.Errorf("expected the invalid expression kept with an error, got %q and %v", value, err)
+	}
+}
@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Workflow struct {
+	Name     string            `yaml:"name"`
+	Env      map[string]string `yaml:"env"`
+	Defaults Defaults          `yaml:"defaults"`
+	Jobs     map[string]Job    `yaml:"jobs"`
+}
+
+type Defaults struct {
+	Run RunDefaults `yaml:"run"`
+}
+
+type RunDefaults struct {
+	Shell            string `yaml:"shell"`
+	WorkingDirectory string `yaml:"working-directory"`
+}
+
+type Job struct {
+	Name            string            `yaml:"name"`
+	RunsOn          RunsOn            `yaml:"runs-on"`
+	Needs           StringList        `yaml:"needs"`
+	If              string            `yaml:"if"`
+	Env             map[string]string `yaml:"env"`
+	Defaults        Defaults          `yaml:"defaults"`
+	ContinueOnError bool              `yaml:"continue-on-error"`
+	Steps           []Step            `yaml:"steps"`
+}
+
+type Step struct {
+	ID               string            `yaml:"id"`
+	Name             string            `yaml:"name"`
+	If               string            `yaml:"if"`
+	Uses             string            `yaml:"uses"`
+	Run              string            `yaml:"run"`
+	Shell            string            `yaml:"shell"`
+	WorkingDirectory string            `yaml:"working-directory"`
+	Env              map[string]string `yaml:"env"`
+	ContinueOnError  bool              `yaml:"continue-on-error"`
+}
+
+type StringList []string
+
+func (s *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = StringList{value.Value}
+		return nil
+	}
+
+	var values []string
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+
+	*s = values
+	return nil
+}
+
+type RunsOn []string
+
+func (r *RunsOn) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*r = RunsOn{value.Value}
+	case yaml.SequenceNode:
+		var labels []string
+		if err := value.Decode(&labels); err != nil {
+			return err
+		}
+		*r = labels
+	case yaml.MappingNode:
+		var group struct {
+			Labels RunsOn `yaml:"labels"`
+		}
+		if err := value.Decode(&group); err != nil {
+			return err
+		}
+		*r = group.Labels
+	}
+
+	return nil
+}
+
+var fileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+\.ya?ml$`)
+
+var scriptPathPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// ValidateFileName accepts only a file directly inside .github/workflows.
+func ValidateFileName(fileName string) error {
+	if !fileNamePattern.MatchString(fileName) || strings.HasPrefix(fileName, ".") {
+		return errors.New("The field actionToRun must be a .yml or .yaml file name of directory .github/workflows")
+	}
+
+	return nil
+}
+
+// ValidateScriptPath accepts only relative paths inside the repository.
+func ValidateScriptPath(scriptPath string) error {
+	cleaned := path.Clean(scriptPath)
+	if !scriptPathPattern.MatchString(scriptPath) || path.IsAbs(cleaned) ||
+		cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.HasPrefix(scriptPath, "-") {
+		return errors.New("The field actionToRun must be a relative path of the repository")
+	}
+
+	return nil
+}
+
+func Parse(content []byte) (Workflow, error) {
+	workflow := Workflow{}
+	err := yaml.Unmarshal(content, &workflow)
+	return workflow, err
+}
+
+var githubHostedPrefixes = []string{"ubuntu-", "windows-", "macos-"}
+
+func isGithubHosted(label string) bool {
+	for _, prefix := range githubHostedPrefixes {
+		if strings.HasPrefix(label, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunnerLabels returns the self-hosted labels required by the jobs of the
+// workflow, ignoring GitHub hosted images and expressions.
+func (w Workflow) RunnerLabels() []string {
+	labels := []string{}
+	for _, job := range w.Jobs {
+		for _, label := range job.RunsOn {
+			label = strings.ToLower(strings.TrimSpace(label))
+			if len(label) == 0 || label == "self-hosted" ||
+				isGithubHosted(label) || strings.Contains(label, "${{") {
+				continue
+			}
+
+			labels = append(labels, label)
+		}
+	}
+
+	return labels
+}
+
+// JobsOrder returns the job ids sorted so every job comes after the jobs it needs.
+func (w Workflow) JobsOrder() ([]string, error) {
+	ids := []string{}
+	for id := range w.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	order := []string{}
+	state := map[string]int{}
+	var visit func(id string) error
+	visit = func(id string) error {
+		job, ok := w.Jobs[id]
+		if !ok {
+			return fmt.Errorf("the job %s doesn't exist", id)
+		}
+
+		switch state[id] {
+		case 1:
+			return fmt.Errorf("the job %s has circular needs", id)
+		case 2:
+			return nil
+		}
+
+		state[id] = 1
+		for _, need := range job.Needs {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		state[id] = 2
+		order = append(order, id)
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := visit(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
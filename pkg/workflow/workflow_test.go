@@ -0,0 +1,59 @@
+package workflow
+
+import "testing"
+
+func TestValidateFileName(t *testing.T) {
+	tests := []struct {
+		fileName string
+		valid    bool
+	}{
+		{"deploy.yml", true},
+		{"deploy.yaml", true},
+		{"build-and_test.v2.yml", true},
+		{"deploy", false},
+		{"deploy.sh", false},
+		{".hidden.yml", false},
+		{"../deploy.yml", false},
+		{"../../.ssh/id.yml", false},
+		{"workflows/deploy.yml", false},
+		{"/etc/deploy.yml", false},
+		{"deploy .yml", false},
+		{"deploy.yml\n", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateFileName(test.fileName); (err == nil) != test.valid {
+			t.Errorf("ValidateFileName(%q) = %v, expected valid %v", test.fileName, err, test.valid)
+		}
+	}
+}
+
+func TestValidateScriptPath(t *testing.T) {
+	tests := []struct {
+		scriptPath string
+		valid      bool
+	}{
+		{"ci.sh", true},
+		{"scripts/ci.sh", true},
+		{"./scripts/ci.sh", true},
+		{"scripts/../ci.sh", true},
+		{"..", false},
+		{"../ci.sh", false},
+		{"scripts/../../ci.sh", false},
+		{"/etc/passwd", false},
+		{"/ci.sh", false},
+		{"-c", false},
+		{"--rcfile=ci.sh", false},
+		{"ci.sh; rm -rf /", false},
+		{"$(whoami).sh", false},
+		{"ci .sh", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateScriptPath(test.scriptPath); (err == nil) != test.valid {
+			t.Errorf("ValidateScriptPath(%q) = %v, expected valid %v", test.scriptPath, err, test.valid)
+		}
+	}
+}
@@ -7,4 +7,8 @@ echo "Creating directory 'pipelines'"
 mkdir ../pipelines
 
 echo "Creating sqlite database file"
-touch database
\ No newline at end of file
+touch database
+if [ -f .env ] && grep -q "^ENCRYPTION_KEYS=$" .env; then
+  echo "Generating a development key on ENCRYPTION_KEYS"
+  sed -i.bak "s|^ENCRYPTION_KEYS=$|ENCRYPTION_KEYS=\"dev:$(openssl rand -base64 32)\"|" .env && rm .env.bak
+fi